@@ -0,0 +1,4 @@
+ephemeral "azurecnp_subscription_pool_lease" "example" {
+  target_management_group_name = "cn-hosting"
+  target_subscription_name     = "josto-ci-run"
+}
@@ -0,0 +1,7 @@
+list "azurecnp_subscription_pool_lease" "example" {
+  provider = azurecnp
+
+  config {
+    management_group_name = "cn-hosting"
+  }
+}
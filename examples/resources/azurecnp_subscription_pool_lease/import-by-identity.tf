@@ -0,0 +1,15 @@
+import {
+  to = azurecnp_subscription_pool_lease.example
+  identity = {
+    subscription_id = "00000000-0000-0000-0000-000000000000"
+  }
+}
+
+# Leases of a named pool also need the pool, so the subscription is looked up in the pool's tenant.
+import {
+  to = azurecnp_subscription_pool_lease.sandbox
+  identity = {
+    subscription_id = "00000000-0000-0000-0000-000000000001"
+    pool            = "sandbox"
+  }
+}
@@ -2,39 +2,270 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"sync"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/managementgroups/armmanagementgroups"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/subscription/armsubscription"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 )
 
 type BaseClient struct {
 	managementGroupClientFactory *armmanagementgroups.ClientFactory
 	subscriptionClientFactory    *armsubscription.ClientFactory
-	availableSubscriptions       chan string
 	poolManagementGroupId        string
 	poolSubscriptionPrefix       string
+	// pools holds the additional named pools of the provider configuration.
+	// Each one is a BaseClient of its own, sharing the client factories of its tenant.
+	pools map[string]*BaseClient
+
+	// connect creates the client factories of the tenant. It runs when the pool is first used,
+	// so runs that don't need the pool never contact Azure for it.
+	connect      func(ctx context.Context) (*tenantClientFactories, diag.Diagnostics)
+	connectMu    sync.Mutex
+	connected    bool
+	connectDiags diag.Diagnostics
+	armClient    *arm.Client
+
+	// skipPoolValidation skips checking the permissions on the pool management group before the first lease.
+	skipPoolValidation bool
+
+	// hierarchy caches the subscriptions of the tenant, shared with the other pools of the tenant.
+	hierarchy *hierarchyCache
+	// rateLimiter smooths the requests of the tenant, shared with the other pools of the tenant.
+	rateLimiter *rateLimiter
+
+	// availableSubscriptions is filled on the first lease, see NextAvailableSubscription.
+	availableSubscriptions   chan string
+	availableSubscriptionsMu sync.Mutex
+}
+
+// Pool returns the connected client of the named pool, or of the default pool for an empty name.
+func (b *BaseClient) Pool(ctx context.Context, name string) (*BaseClient, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	pool := b
+	if name != "" {
+		var ok bool
+		pool, ok = b.pools[name]
+		if !ok {
+			diags.AddAttributeError(
+				path.Root("pool"),
+				"Unknown subscription pool",
+				fmt.Sprintf("No subscription pool '%s' is configured in the provider.", name),
+			)
+			return nil, diags
+		}
+	}
+
+	pool.connectMu.Lock()
+	defer pool.connectMu.Unlock()
+	if !pool.connected && !pool.connectDiags.HasError() {
+		factories, connectDiags := pool.connect(ctx)
+		if factories == nil && ctx.Err() != nil {
+			// Failures caused by the caller's context, e.g. a timeout, are not kept, so the next caller tries again.
+			diags.Append(connectDiags...)
+			return pool, diags
+		}
+		pool.connectDiags = connectDiags
+		if factories != nil {
+			pool.armClient = factories.armClient
+			pool.managementGroupClientFactory = factories.managementGroupClientFactory
+			pool.subscriptionClientFactory = factories.subscriptionClientFactory
+			pool.hierarchy = factories.hierarchy
+			pool.rateLimiter = factories.rateLimiter
+			pool.connected = true
+		}
+	}
+	diags.Append(pool.connectDiags...)
+	return pool, diags
+}
+
+// NextAvailableSubscription takes a subscription from the pool, or returns a PoolExhaustedError.
+// The pool is validated and listed on the first call.
+func (b *BaseClient) NextAvailableSubscription(ctx context.Context) (string, error) {
+	availableSubscriptions, err := b.loadAvailableSubscriptions(ctx)
+	if err != nil {
+		return "", err
+	}
+	subscriptionId, ok := <-availableSubscriptions
+	if !ok {
+		return "", PoolExhaustedError{ManagementGroupId: b.poolManagementGroupId, SubscriptionPrefix: b.poolSubscriptionPrefix}
+	}
+	return subscriptionId, nil
 }
 
-func (b BaseClient) RenameSubscription(subscriptionId string, name string) (armsubscription.ClientRenameResponse, error) {
-	return b.subscriptionClientFactory.NewClient().Rename(context.Background(), subscriptionId, armsubscription.Name{SubscriptionName: &name}, nil)
+// loadAvailableSubscriptions validates the permissions on the pool and lists its subscriptions, unless that already
+// succeeded. Failures are not kept, so the next lease tries again.
+func (b *BaseClient) loadAvailableSubscriptions(ctx context.Context) (chan string, error) {
+	b.availableSubscriptionsMu.Lock()
+	defer b.availableSubscriptionsMu.Unlock()
+	if b.availableSubscriptions != nil {
+		return b.availableSubscriptions, nil
+	}
+
+	if err := b.validatePool(ctx); err != nil {
+		return nil, err
+	}
+	subscriptions, err := b.hierarchy.listManagementGroup(ctx, b.poolManagementGroupId)
+	if err != nil {
+		return nil, b.classifyError(err)
+	}
+	b.availableSubscriptions = findAvailableSubscriptions(subscriptions, b.poolManagementGroupId, b.poolSubscriptionPrefix)
+	return b.availableSubscriptions, nil
 }
 
-func (b BaseClient) MoveSubscription(subscriptionId string, managementGroupId string) (armmanagementgroups.ManagementGroupSubscriptionsClientCreateResponse, error) {
-	return b.managementGroupClientFactory.NewManagementGroupSubscriptionsClient().Create(context.Background(), managementGroupId, subscriptionId, nil)
+// validatePool checks that the provider's identity may lease subscriptions from the pool management group.
+// It only runs before leasing, so reading and returning existing leases doesn't depend on the pool.
+func (b *BaseClient) validatePool(ctx context.Context) error {
+	if b.skipPoolValidation {
+		return nil
+	}
+	missingActions, err := findMissingPoolActions(ctx, b.armClient, b.poolManagementGroupId)
+	if err != nil {
+		return fmt.Errorf("could not read the effective permissions on ManagementGroup '%s', set skip_credentials_validation to skip this check: %w", b.poolManagementGroupId, b.classifyError(err))
+	}
+	if len(missingActions) > 0 {
+		return MissingPermissionsError{Scope: managementGroupIdPrefix + b.poolManagementGroupId, Actions: missingActions}
+	}
+	return nil
+}
+
+// classifyError wraps ARM errors into typed errors, see the package level classifyError,
+// and tells permission errors which management group is the pool.
+func (b *BaseClient) classifyError(err error) error {
+	err = classifyError(err)
+	if permissionDenied, ok := err.(PermissionDeniedError); ok {
+		permissionDenied.PoolManagementGroupId = b.poolManagementGroupId
+		return permissionDenied
+	}
+	return err
 }
 
-func (b BaseClient) ReadSubscriptionState(subscriptionId string) (*armmanagementgroups.EntityInfo, error) {
-	pager := b.managementGroupClientFactory.NewEntitiesClient().NewListPager(nil)
+// isInPool reports whether a subscription with this parent and display name belongs to the pool, i.e. is free to be leased.
+func (b *BaseClient) isInPool(parentManagementGroup string, displayName string) bool {
+	return isPoolSubscription(parentManagementGroup, displayName, b.poolManagementGroupId, b.poolSubscriptionPrefix)
+}
+
+// isPoolSubscription reports whether a subscription can be leased from the pool: it is placed under the pool
+// management group and carries the pool prefix. A subscription with only one of both is leased by nobody.
+func isPoolSubscription(parentManagementGroup string, displayName string, poolManagementGroupId string, poolSubscriptionPrefix string) bool {
+	return strings.EqualFold(parentManagementGroup, poolManagementGroupId) && strings.HasPrefix(displayName, poolSubscriptionPrefix)
+}
+
+// RenameSubscription renames the subscription. Throttled requests are retried according to the retry settings.
+func (b *BaseClient) RenameSubscription(ctx context.Context, subscriptionId string, name string) (armsubscription.ClientRenameResponse, error) {
+	if err := b.rateLimiter.wait(ctx, operationRename); err != nil {
+		return armsubscription.ClientRenameResponse{}, err
+	}
+	ctx, attempts := withAttemptCounter(ctx)
+	response, err := b.subscriptionClientFactory.NewClient().Rename(ctx, subscriptionId, armsubscription.Name{SubscriptionName: &name}, nil)
+	b.hierarchy.invalidate(subscriptionId)
+	return response, withRetryCount(b.classifyError(err), attempts)
+}
+
+// MoveSubscription moves the subscription from its current management group, the source, under the target management group.
+// Throttled requests are retried according to the retry settings.
+func (b *BaseClient) MoveSubscription(ctx context.Context, subscriptionId string, sourceManagementGroupId string, targetManagementGroupId string) (armmanagementgroups.ManagementGroupSubscriptionsClientCreateResponse, error) {
+	if err := b.rateLimiter.wait(ctx, operationMove); err != nil {
+		return armmanagementgroups.ManagementGroupSubscriptionsClientCreateResponse{}, err
+	}
+	ctx, attempts := withAttemptCounter(ctx)
+	response, err := b.managementGroupClientFactory.NewManagementGroupSubscriptionsClient().Create(ctx, targetManagementGroupId, subscriptionId, nil)
+	b.hierarchy.invalidate(subscriptionId)
+	err = b.classifyError(err)
+	if permissionDenied, ok := err.(PermissionDeniedError); ok {
+		permissionDenied.SourceManagementGroupId = sourceManagementGroupId
+		permissionDenied.TargetManagementGroupId = targetManagementGroupId
+		err = permissionDenied
+	}
+	return response, withRetryCount(err, attempts)
+}
+
+// GetSubscription reads the subscription under the management group, within the read rate limit.
+func (b *BaseClient) GetSubscription(ctx context.Context, managementGroupId string, subscriptionId string) (armmanagementgroups.ManagementGroupSubscriptionsClientGetSubscriptionResponse, error) {
+	if err := b.rateLimiter.wait(ctx, operationRead); err != nil {
+		return armmanagementgroups.ManagementGroupSubscriptionsClientGetSubscriptionResponse{}, err
+	}
+	return b.managementGroupClientFactory.NewManagementGroupSubscriptionsClient().GetSubscription(ctx, managementGroupId, subscriptionId, nil)
+}
+
+// subscriptionState is the placement and display name of a subscription in the management group hierarchy.
+type subscriptionState struct {
+	subscriptionId        string
+	displayName           string
+	parentManagementGroup string
+}
+
+// ReadSubscriptionState serves the subscription from the hierarchy cache if it was listed or read before and we didn't
+// change it since. Otherwise it is looked up under its last known parent management group, which is a single request
+// as long as nobody moved it, or without a known parent, searched in the hierarchy by name. It never lists the whole hierarchy.
+func (b *BaseClient) ReadSubscriptionState(ctx context.Context, subscriptionId string, lastKnownParent string) (*subscriptionState, error) {
+	if cached, ok := b.hierarchy.lookup(subscriptionId); ok {
+		return &cached, nil
+	}
+
+	subscription, err := b.readSubscriptionState(ctx, subscriptionId, lastKnownParent)
+	if err != nil {
+		return nil, err
+	}
+	b.hierarchy.store(*subscription)
+	return subscription, nil
+}
+
+func (b *BaseClient) readSubscriptionState(ctx context.Context, subscriptionId string, lastKnownParent string) (*subscriptionState, error) {
+	if lastKnownParent != "" {
+		sub, err := b.GetSubscription(ctx, lastKnownParent, subscriptionId)
+		if err == nil && sub.Properties != nil && sub.Properties.Parent != nil {
+			return &subscriptionState{
+				subscriptionId:        *sub.Name,
+				displayName:           *sub.Properties.DisplayName,
+				parentManagementGroup: strings.TrimPrefix(*sub.Properties.Parent.ID, managementGroupIdPrefix),
+			}, nil
+		}
+		if err != nil && !isNotFound(err) {
+			return nil, b.classifyError(err)
+		}
+	}
+
+	filter := fmt.Sprintf("name eq '%s'", subscriptionId)
+	pager := b.managementGroupClientFactory.NewEntitiesClient().NewListPager(&armmanagementgroups.EntitiesClientListOptions{Filter: &filter})
 	for pager.More() {
-		page, err := pager.NextPage(context.Background())
-		if err != nil {
+		if err := b.rateLimiter.wait(ctx, operationRead); err != nil {
 			return nil, err
 		}
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, b.classifyError(err)
+		}
 		for _, entityInfo := range page.Value {
-			if *entityInfo.Type == "/subscriptions" && *entityInfo.Name == subscriptionId {
-				return entityInfo, nil
+			if *entityInfo.Type == "/subscriptions" && *entityInfo.Name == subscriptionId && entityInfo.Properties != nil && entityInfo.Properties.Parent != nil {
+				return &subscriptionState{
+					subscriptionId:        *entityInfo.Name,
+					displayName:           *entityInfo.Properties.DisplayName,
+					parentManagementGroup: strings.TrimPrefix(*entityInfo.Properties.Parent.ID, managementGroupIdPrefix),
+				}, nil
 			}
 		}
 	}
 	return nil, NewNoSubscriptionsFoundError(subscriptionId)
 }
+
+// ListSubscriptionStates returns the subscriptions directly under the management group,
+// or all subscriptions of the tenant's hierarchy for an empty management group.
+func (b *BaseClient) ListSubscriptionStates(ctx context.Context, managementGroupId string) ([]subscriptionState, error) {
+	var subscriptions []subscriptionState
+	var err error
+	if managementGroupId != "" {
+		subscriptions, err = b.hierarchy.listManagementGroup(ctx, managementGroupId)
+	} else {
+		subscriptions, err = b.hierarchy.listTenant(ctx)
+	}
+	if err != nil {
+		return nil, b.classifyError(err)
+	}
+	return subscriptions, nil
+}
@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// cloudEnvironments maps the supported values of the environment setting to their Azure cloud.
+var cloudEnvironments = map[string]cloud.Configuration{
+	"public":       cloud.AzurePublic,
+	"usgovernment": cloud.AzureGovernment,
+	"china":        cloud.AzureChina,
+}
+
+// clientOptionsConfig collects the settings that decide where and how requests to Azure are sent.
+type clientOptionsConfig struct {
+	cloud                   cloud.Configuration
+	resourceManagerEndpoint string
+	authorityHost           string
+	insecureSkipTlsVerify   bool
+	caCertificatePath       string
+	retry                   policy.RetryOptions
+}
+
+// newClientOptions builds the client options shared by the credential and both client factories.
+func newClientOptions(config clientOptionsConfig) (azcore.ClientOptions, error) {
+	// Copy the cloud configuration, the predefined clouds share their Services map with everyone else.
+	cloudConfiguration := cloud.Configuration{
+		ActiveDirectoryAuthorityHost: config.cloud.ActiveDirectoryAuthorityHost,
+		Services:                     maps.Clone(config.cloud.Services),
+	}
+
+	if config.authorityHost != "" {
+		cloudConfiguration.ActiveDirectoryAuthorityHost = config.authorityHost
+	}
+
+	if config.resourceManagerEndpoint != "" {
+		resourceManager := cloudConfiguration.Services[cloud.ResourceManager]
+		resourceManager.Endpoint = config.resourceManagerEndpoint
+		cloudConfiguration.Services[cloud.ResourceManager] = resourceManager
+	}
+
+	options := azcore.ClientOptions{
+		Cloud:            cloudConfiguration,
+		Retry:            config.retry,
+		PerRetryPolicies: []policy.Policy{attemptCountingPolicy{}},
+	}
+
+	if config.insecureSkipTlsVerify || config.caCertificatePath != "" {
+		tlsConfig := &tls.Config{
+			InsecureSkipVerify: config.insecureSkipTlsVerify,
+		}
+
+		if config.caCertificatePath != "" {
+			caCertificate, err := os.ReadFile(config.caCertificatePath)
+			if err != nil {
+				return options, fmt.Errorf("reading CA certificate: %w", err)
+			}
+			certPool, err := x509.SystemCertPool()
+			if err != nil {
+				certPool = x509.NewCertPool()
+			}
+			if !certPool.AppendCertsFromPEM(caCertificate) {
+				return options, fmt.Errorf("CA certificate '%s' does not contain any PEM encoded certificate", config.caCertificatePath)
+			}
+			tlsConfig.RootCAs = certPool
+		}
+
+		options.Transport = &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tlsConfig,
+			},
+		}
+	}
+
+	return options, nil
+}
@@ -0,0 +1,292 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// oidcAudience is the audience Entra ID expects in federated ID tokens.
+const oidcAudience = "api://AzureADTokenExchange"
+
+// credentialConfig collects the authentication settings resolved from the provider configuration and environment.
+// Pools in the same tenant share one credential, so the config has to stay comparable.
+type credentialConfig struct {
+	// disableInstanceDiscovery skips the Entra ID metadata request, which custom authority hosts usually can't answer.
+	disableInstanceDiscovery bool
+
+	tenantId             string
+	clientId             string
+	clientSecret         string
+	clientSecretFilePath string
+	useCli               bool
+	useMsi               bool
+
+	clientCertificate                 string
+	clientCertificatePath             string
+	clientCertificatePassword         string
+	clientCertificatePasswordFilePath string
+
+	useOidc           bool
+	oidcToken         string
+	oidcTokenFilePath string
+	oidcRequestUrl    string
+	oidcRequestToken  string
+}
+
+// newCredential builds the credential for the configured authentication method.
+// Explicit switches take precedence, then a configured client certificate or client secret. Without either,
+// the environment, managed identity and Azure CLI credentials are tried in that order.
+func newCredential(config credentialConfig, clientOptions azcore.ClientOptions) (azcore.TokenCredential, error) {
+	switch {
+	case config.useCli:
+		return newAzureCLICredential(config)
+	case config.useMsi:
+		return newManagedIdentityCredential(config, clientOptions)
+	case config.useOidc:
+		return azidentity.NewClientAssertionCredential(config.tenantId, config.clientId, config.getOidcAssertion, &azidentity.ClientAssertionCredentialOptions{ClientOptions: clientOptions, DisableInstanceDiscovery: config.disableInstanceDiscovery})
+	case config.clientCertificate != "" || config.clientCertificatePath != "":
+		return newClientCertificateCredential(config, clientOptions)
+	case config.clientSecret != "" || config.clientSecretFilePath != "":
+		return newClientSecretCredential(config, clientOptions)
+	}
+
+	var sources []azcore.TokenCredential
+	// The environment credential fails to build when no AZURE_* variables are set; it's simply left out of the chain then.
+	if environmentCredential, err := azidentity.NewEnvironmentCredential(&azidentity.EnvironmentCredentialOptions{ClientOptions: clientOptions, DisableInstanceDiscovery: config.disableInstanceDiscovery}); err == nil {
+		sources = append(sources, environmentCredential)
+	}
+
+	managedIdentityCredential, err := newManagedIdentityCredential(config, clientOptions)
+	if err != nil {
+		return nil, err
+	}
+	sources = append(sources, managedIdentityCredential)
+
+	cliCredential, err := newAzureCLICredential(config)
+	if err != nil {
+		return nil, err
+	}
+	sources = append(sources, cliCredential)
+
+	return azidentity.NewChainedTokenCredential(sources, nil)
+}
+
+func newAzureCLICredential(config credentialConfig) (azcore.TokenCredential, error) {
+	return azidentity.NewAzureCLICredential(&azidentity.AzureCLICredentialOptions{
+		TenantID: config.tenantId,
+	})
+}
+
+func newManagedIdentityCredential(config credentialConfig, clientOptions azcore.ClientOptions) (azcore.TokenCredential, error) {
+	options := azidentity.ManagedIdentityCredentialOptions{ClientOptions: clientOptions}
+	if config.clientId != "" {
+		options.ID = azidentity.ClientID(config.clientId)
+	}
+	return azidentity.NewManagedIdentityCredential(&options)
+}
+
+// newClientSecretCredential uses the client secret from the configuration or from a file.
+func newClientSecretCredential(config credentialConfig, clientOptions azcore.ClientOptions) (azcore.TokenCredential, error) {
+	clientSecret := config.clientSecret
+	if config.clientSecretFilePath != "" {
+		var err error
+		clientSecret, err = readSecretFile(config.clientSecretFilePath)
+		if err != nil {
+			return nil, fmt.Errorf("reading client secret: %w", err)
+		}
+	}
+
+	return azidentity.NewClientSecretCredential(config.tenantId, config.clientId, clientSecret, &azidentity.ClientSecretCredentialOptions{ClientOptions: clientOptions, DisableInstanceDiscovery: config.disableInstanceDiscovery})
+}
+
+// newClientCertificateCredential loads a PEM or PKCS#12 certificate either from a file or from its base64 encoded content.
+func newClientCertificateCredential(config credentialConfig, clientOptions azcore.ClientOptions) (azcore.TokenCredential, error) {
+	var certificateData []byte
+	var err error
+	if config.clientCertificatePath != "" {
+		certificateData, err = os.ReadFile(config.clientCertificatePath)
+		if err != nil {
+			return nil, fmt.Errorf("reading client certificate: %w", err)
+		}
+	} else {
+		certificateData, err = base64.StdEncoding.DecodeString(config.clientCertificate)
+		if err != nil {
+			return nil, fmt.Errorf("decoding client certificate: %w", err)
+		}
+	}
+
+	var password []byte
+	if config.clientCertificatePasswordFilePath != "" {
+		filePassword, err := readSecretFile(config.clientCertificatePasswordFilePath)
+		if err != nil {
+			return nil, fmt.Errorf("reading client certificate password: %w", err)
+		}
+		password = []byte(filePassword)
+	} else if config.clientCertificatePassword != "" {
+		password = []byte(config.clientCertificatePassword)
+	}
+
+	certificates, key, err := azidentity.ParseCertificates(certificateData, password)
+	if err != nil {
+		return nil, fmt.Errorf("parsing client certificate: %w", err)
+	}
+
+	return azidentity.NewClientCertificateCredential(config.tenantId, config.clientId, certificates, key, &azidentity.ClientCertificateCredentialOptions{ClientOptions: clientOptions, DisableInstanceDiscovery: config.disableInstanceDiscovery})
+}
+
+// readSecretFile reads a secret mounted as a file, ignoring the trailing newline most tools write.
+// Errors only mention the path, so the secret can't leak into diagnostics.
+func readSecretFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	secret := strings.TrimSpace(string(content))
+	if secret == "" {
+		return "", fmt.Errorf("%s is empty", path)
+	}
+	return secret, nil
+}
+
+// countSecretSources returns how many of the mutually exclusive client secret settings are set.
+func (config credentialConfig) countSecretSources() int {
+	return countNonEmpty(config.clientSecret, config.clientSecretFilePath, config.clientCertificate, config.clientCertificatePath)
+}
+
+// validate checks the resolved credential settings of the provider, for an empty base path, or of the pool at the base path.
+func (config credentialConfig) validate(base path.Path) diag.Diagnostics {
+	var diags diag.Diagnostics
+	addError := func(attributePath path.Path, summary string, detail string) {
+		if attributePath.Equal(path.Empty()) {
+			diags.AddError(summary, detail)
+			return
+		}
+		diags.AddAttributeError(attributePath, summary, detail)
+	}
+
+	if countTrue(config.useCli, config.useMsi, config.useOidc) > 1 {
+		addError(
+			base,
+			"Conflicting Azure API authentication methods",
+			"Only one of use_cli, use_msi and use_oidc can be enabled.",
+		)
+	}
+
+	if config.useOidc && config.oidcToken == "" && config.oidcTokenFilePath == "" && (config.oidcRequestUrl == "" || config.oidcRequestToken == "") {
+		addError(
+			base.AtName("use_oidc"),
+			"Missing Azure API OIDC token",
+			"The provider cannot authenticate with OIDC as there is no ID token source. "+
+				"Set oidc_token, oidc_token_file_path or both oidc_request_url and oidc_request_token, "+
+				"or use the ARM_OIDC_TOKEN, ARM_OIDC_TOKEN_FILE_PATH, ARM_OIDC_REQUEST_URL and ARM_OIDC_REQUEST_TOKEN environment variables.",
+		)
+	}
+
+	if config.countSecretSources() > 1 {
+		addError(
+			base,
+			"Conflicting Azure API client secrets",
+			"Only one of client_secret, client_secret_file_path, client_certificate and client_certificate_path can be set, "+
+				"including the ARM_CLIENT_SECRET, ARM_CLIENT_SECRET_FILE_PATH, ARM_CLIENT_CERTIFICATE and ARM_CLIENT_CERTIFICATE_PATH environment variables.",
+		)
+	}
+
+	if config.clientCertificatePassword != "" && config.clientCertificatePasswordFilePath != "" {
+		addError(
+			base.AtName("client_certificate_password"),
+			"Conflicting Azure API client certificate passwords",
+			"Only one of client_certificate_password and client_certificate_password_file_path can be set.",
+		)
+	}
+
+	requiresServicePrincipal := config.countSecretSources() > 0 || config.useOidc
+
+	if requiresServicePrincipal && config.tenantId == "" {
+		addError(
+			base.AtName("tenant_id"),
+			"Missing Azure API TenantId",
+			"The provider cannot create the Azure API client as there is a missing or empty value for the Azure API tenant_id. "+
+				"Set the tenant_id value in the configuration or use the ARM_TENANT_ID environment variable. "+
+				"If either is already set, ensure the value is not empty.",
+		)
+	}
+
+	if requiresServicePrincipal && config.clientId == "" {
+		addError(
+			base.AtName("client_id"),
+			"Missing Azure API ClientId",
+			"The provider cannot create the Azure API client as there is a missing or empty value for the Azure API client_id. "+
+				"Set the client_id value in the configuration or use the ARM_CLIENT_ID environment variable. "+
+				"If either is already set, ensure the value is not empty.",
+		)
+	}
+
+	return diags
+}
+
+// getOidcAssertion returns the federated ID token, preferring a static token over a token file over
+// requesting a fresh token from the CI system (e.g. the GitHub Actions ID token endpoint).
+func (config credentialConfig) getOidcAssertion(ctx context.Context) (string, error) {
+	if config.oidcToken != "" {
+		return config.oidcToken, nil
+	}
+
+	if config.oidcTokenFilePath != "" {
+		token, err := os.ReadFile(config.oidcTokenFilePath)
+		if err != nil {
+			return "", fmt.Errorf("reading OIDC token file: %w", err)
+		}
+		return strings.TrimSpace(string(token)), nil
+	}
+
+	if config.oidcRequestUrl == "" || config.oidcRequestToken == "" {
+		return "", fmt.Errorf("no OIDC token configured; set oidc_token, oidc_token_file_path or both oidc_request_url and oidc_request_token")
+	}
+
+	requestUrl, err := url.Parse(config.oidcRequestUrl)
+	if err != nil {
+		return "", fmt.Errorf("parsing OIDC request URL: %w", err)
+	}
+	query := requestUrl.Query()
+	query.Set("audience", oidcAudience)
+	requestUrl.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestUrl.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("building OIDC token request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+config.oidcRequestToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("requesting OIDC token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("requesting OIDC token: unexpected status %s", resp.Status)
+	}
+
+	var body struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decoding OIDC token response: %w", err)
+	}
+	if body.Value == "" {
+		return "", fmt.Errorf("OIDC token response did not contain a token")
+	}
+	return body.Value, nil
+}
@@ -1,6 +1,16 @@
 package provider
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
 
 type NoSubscriptionsFoundError struct {
 	SubscriptionId string
@@ -15,3 +25,208 @@ func NewNoSubscriptionsFoundError(subscriptionId string) NoSubscriptionsFoundErr
 		SubscriptionId: subscriptionId,
 	}
 }
+
+// PoolExhaustedError is returned when the pool has no free subscription left.
+type PoolExhaustedError struct {
+	ManagementGroupId  string
+	SubscriptionPrefix string
+}
+
+func (e PoolExhaustedError) Error() string {
+	return fmt.Sprintf("no subscription with prefix '%s' left in ManagementGroup '%s'", e.SubscriptionPrefix, e.ManagementGroupId)
+}
+
+// MissingPermissionsError is returned before the first lease when the provider's identity lacks permissions on the pool.
+type MissingPermissionsError struct {
+	Scope   string
+	Actions []string
+}
+
+func (e MissingPermissionsError) Error() string {
+	return formatMissingActions(e.Actions, e.Scope)
+}
+
+// LeaseConflictError is returned when ARM rejects a change because the subscription is being changed by someone else.
+type LeaseConflictError struct {
+	Err *azcore.ResponseError
+}
+
+func (e LeaseConflictError) Error() string { return e.Err.Error() }
+func (e LeaseConflictError) Unwrap() error { return e.Err }
+
+// PermissionDeniedError is returned when the provider's identity lacks a role assignment.
+// For AuthorizationFailed errors, the denied action, the principal and the scope are parsed from the message.
+type PermissionDeniedError struct {
+	Err *azcore.ResponseError
+
+	Principal         string
+	PrincipalObjectId string
+	Action            string
+	Scope             string
+	// PoolManagementGroupId tells the pool management group apart from other management groups.
+	PoolManagementGroupId string
+	// SourceManagementGroupId and TargetManagementGroupId are set for a denied move: the management group
+	// the subscription was moved from and the one it was moved to. A move needs permissions on both.
+	SourceManagementGroupId string
+	TargetManagementGroupId string
+}
+
+func (e PermissionDeniedError) Error() string { return e.Err.Error() }
+func (e PermissionDeniedError) Unwrap() error { return e.Err }
+
+// ThrottledError is returned when ARM kept throttling a request after all retries.
+type ThrottledError struct {
+	Err *azcore.ResponseError
+}
+
+func (e ThrottledError) Error() string { return e.Err.Error() }
+func (e ThrottledError) Unwrap() error { return e.Err }
+
+// NotFoundError is returned when ARM doesn't know the subscription or management group.
+type NotFoundError struct {
+	Err *azcore.ResponseError
+}
+
+func (e NotFoundError) Error() string { return e.Err.Error() }
+func (e NotFoundError) Unwrap() error { return e.Err }
+
+// authorizationFailedPattern matches the message of ARM's AuthorizationFailed error, e.g. "The client 'app@contoso.com'
+// with object id '00000000-...' does not have authorization to perform action 'Microsoft.X/y/write' over scope '/...'".
+var authorizationFailedPattern = regexp.MustCompile(`The client '([^']*)' with object id '([^']*)' does not have authorization to perform action '([^']*)' over scope '([^']*)'`)
+
+func newPermissionDeniedError(responseError *azcore.ResponseError) PermissionDeniedError {
+	permissionDenied := PermissionDeniedError{Err: responseError}
+	if match := authorizationFailedPattern.FindStringSubmatch(responseError.Error()); match != nil {
+		permissionDenied.Principal = match[1]
+		permissionDenied.PrincipalObjectId = match[2]
+		permissionDenied.Action = match[3]
+		permissionDenied.Scope = match[4]
+	}
+	return permissionDenied
+}
+
+// explain tells which role is missing where, in terms of the lease: the pool management group,
+// the source or target management group of a move, or the subscription.
+func (e PermissionDeniedError) explain() string {
+	scope := fmt.Sprintf("'%s'", e.Scope)
+	assignmentScope := e.Scope
+	switch {
+	case strings.HasPrefix(strings.ToLower(e.Scope), strings.ToLower(managementGroupIdPrefix)):
+		// Moves are authorized on the management group, e.g. /providers/Microsoft.Management/managementGroups/{group}/subscriptions/{id}.
+		managementGroup, _, _ := strings.Cut(e.Scope[len(managementGroupIdPrefix):], "/")
+		assignmentScope = managementGroupIdPrefix + managementGroup
+		switch {
+		case strings.EqualFold(managementGroup, e.PoolManagementGroupId):
+			scope = fmt.Sprintf("the pool management group '%s'", managementGroup)
+		case strings.EqualFold(managementGroup, e.SourceManagementGroupId):
+			scope = fmt.Sprintf("the source management group '%s'", managementGroup)
+		case strings.EqualFold(managementGroup, e.TargetManagementGroupId):
+			scope = fmt.Sprintf("the target management group '%s'", managementGroup)
+		default:
+			scope = fmt.Sprintf("the management group '%s'", managementGroup)
+		}
+	case strings.HasPrefix(strings.ToLower(e.Scope), subscriptionIdPrefix):
+		subscriptionId, _, _ := strings.Cut(e.Scope[len(subscriptionIdPrefix):], "/")
+		assignmentScope = subscriptionIdPrefix + subscriptionId
+		scope = fmt.Sprintf("the subscription '%s'", subscriptionId)
+	}
+
+	var role string
+	switch action := strings.ToLower(e.Action); {
+	case strings.HasPrefix(action, "microsoft.management/managementgroups/"):
+		role = "Management Group Contributor"
+	case strings.HasPrefix(action, "microsoft.subscription/"):
+		role = "Owner or Contributor"
+	case strings.HasPrefix(action, "microsoft.authorization/"):
+		role = "Owner"
+	default:
+		role = fmt.Sprintf("a role that allows '%s'", e.Action)
+	}
+
+	var detail strings.Builder
+	fmt.Fprintf(&detail, "The provider's identity '%s' (object ID %s) is not allowed to perform '%s' on %s.\n\n", e.Principal, e.PrincipalObjectId, e.Action, scope)
+	fmt.Fprintf(&detail, "Assign it %s on %s (scope '%s').\n\n", role, scope, assignmentScope)
+	detail.WriteString("Leasing a subscription moves it from the pool management group to the target management group and renames it. " +
+		"Changing the target or returning the lease moves it from its current management group. This requires:\n" +
+		"  - Management Group Contributor on the management group the subscription is moved from\n" +
+		"  - Management Group Contributor on the management group it is moved to\n" +
+		"  - Owner on the subscription, e.g. inherited from the pool management group\n\n" +
+		"Role assignments can take a few minutes to take effect. ARM error code: " + e.Err.ErrorCode)
+	return detail.String()
+}
+
+// classifyError wraps ARM response errors into the typed error matching their status code.
+// Other errors are returned unchanged.
+func classifyError(err error) error {
+	var responseError *azcore.ResponseError
+	if !errors.As(err, &responseError) {
+		return err
+	}
+	switch responseError.StatusCode {
+	case http.StatusConflict:
+		return LeaseConflictError{Err: responseError}
+	case http.StatusForbidden:
+		return newPermissionDeniedError(responseError)
+	case http.StatusTooManyRequests:
+		return ThrottledError{Err: responseError}
+	case http.StatusNotFound:
+		return NotFoundError{Err: responseError}
+	}
+	return err
+}
+
+// errorDiagnostic turns the error of an operation into a diagnostic. Typed errors get a specific summary,
+// the attribute they concern, the ARM error code and a hint how to resolve them.
+func errorDiagnostic(summary string, err error) diag.Diagnostic {
+	var attributePath path.Path
+	var hint string
+
+	var poolExhausted PoolExhaustedError
+	var missingPermissions MissingPermissionsError
+	var leaseConflict LeaseConflictError
+	var permissionDenied PermissionDeniedError
+	var throttled ThrottledError
+	var notFound NotFoundError
+	var noSubscriptionsFound NoSubscriptionsFoundError
+	switch {
+	case errors.As(err, &poolExhausted):
+		summary = "Subscription pool exhausted"
+		attributePath = path.Root("pool")
+		hint = "Add subscriptions to the pool: move them into the pool management group and give them a display name starting with the pool prefix."
+	case errors.As(err, &missingPermissions):
+		summary = "Missing Azure API permissions"
+		attributePath = path.Root("pool")
+	case errors.As(err, &leaseConflict):
+		summary += ": conflicting change"
+		attributePath = path.Root("subscription_id")
+		hint = "Another run changed the subscription at the same time. Make sure each subscription is only leased by one workspace and apply again."
+	case errors.As(err, &permissionDenied) && permissionDenied.Action != "":
+		summary += ": permission denied"
+		// The explanation ends with the ARM error code; the error itself adds the retries and ARM's message.
+		return diag.NewErrorDiagnostic(summary, permissionDenied.explain()+"\n\n"+err.Error())
+	case errors.As(err, &permissionDenied):
+		summary += ": permission denied"
+		hint = "Grant the provider's identity the missing role assignment, see the error above, and apply again."
+	case errors.As(err, &throttled):
+		summary += ": throttled by Azure"
+		hint = "Azure throttles subscription changes per tenant. Lower -parallelism or the limits in the provider's rate_limit block, or raise max_retries and max_retry_delay in its retry block."
+	case errors.As(err, &notFound), errors.As(err, &noSubscriptionsFound):
+		summary += ": not found"
+		attributePath = path.Root("subscription_id")
+		hint = "The subscription or management group doesn't exist (anymore) or isn't visible to the provider's identity."
+	}
+
+	detail := err.Error()
+	var responseError *azcore.ResponseError
+	if errors.As(err, &responseError) && responseError.ErrorCode != "" {
+		detail += "\n\nARM error code: " + responseError.ErrorCode
+	}
+	if hint != "" {
+		detail += "\n\n" + hint
+	}
+
+	if attributePath.Equal(path.Empty()) {
+		return diag.NewErrorDiagnostic(summary, detail)
+	}
+	return diag.NewAttributeErrorDiagnostic(attributePath, summary, detail)
+}
@@ -0,0 +1,247 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+)
+
+const (
+	testPrincipal         = "terraform@contoso.onmicrosoft.com"
+	testPrincipalObjectId = "11111111-1111-1111-1111-111111111111"
+	testSubscriptionId    = "00000000-0000-0000-0000-000000000001"
+)
+
+// authorizationFailedMessage is the message ARM returns for a denied action.
+func authorizationFailedMessage(action string, scope string) string {
+	return "The client '" + testPrincipal + "' with object id '" + testPrincipalObjectId + "' does not have authorization to perform action '" +
+		action + "' over scope '" + scope + "' or the scope is invalid. If access was recently granted, please refresh your credentials."
+}
+
+// newTestResponseError returns the error azcore reports for an ARM error response.
+func newTestResponseError(t *testing.T, statusCode int, code string, message string) *azcore.ResponseError {
+	t.Helper()
+	body, err := json.Marshal(map[string]any{"error": map[string]string{"code": code, "message": message}})
+	if err != nil {
+		t.Fatalf("unexpected error encoding the response body: %v", err)
+	}
+	err = runtime.NewResponseError(&http.Response{
+		StatusCode: statusCode,
+		Status:     http.StatusText(statusCode),
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(string(body))),
+	})
+	var responseError *azcore.ResponseError
+	if !errors.As(err, &responseError) {
+		t.Fatalf("expected an *azcore.ResponseError, got %T", err)
+	}
+	return responseError
+}
+
+func TestAuthorizationFailedPattern(t *testing.T) {
+	testCases := map[string]struct {
+		message       string
+		expectedMatch []string
+	}{
+		"move": {
+			message: authorizationFailedMessage("Microsoft.Management/managementGroups/subscriptions/write",
+				"/providers/Microsoft.Management/managementGroups/pool/subscriptions/"+testSubscriptionId),
+			expectedMatch: []string{testPrincipal, testPrincipalObjectId, "Microsoft.Management/managementGroups/subscriptions/write",
+				"/providers/Microsoft.Management/managementGroups/pool/subscriptions/" + testSubscriptionId},
+		},
+		"rename": {
+			message:       authorizationFailedMessage("Microsoft.Subscription/rename/action", "/subscriptions/"+testSubscriptionId),
+			expectedMatch: []string{testPrincipal, testPrincipalObjectId, "Microsoft.Subscription/rename/action", "/subscriptions/" + testSubscriptionId},
+		},
+		"linked access check": {
+			message: "The client '" + testPrincipal + "' with object id '" + testPrincipalObjectId + "' has permission to perform action " +
+				"'Microsoft.Management/managementGroups/subscriptions/write' on scope '/providers/Microsoft.Management/managementGroups/pool'; " +
+				"however, it does not have permission to perform action(s) 'Microsoft.Management/managementGroups/write' on the linked scope(s).",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			match := authorizationFailedPattern.FindStringSubmatch(testCase.message)
+			if testCase.expectedMatch == nil {
+				if match != nil {
+					t.Fatalf("expected no match, got %q", match)
+				}
+				return
+			}
+			if match == nil {
+				t.Fatal("expected a match")
+			}
+			for i, expected := range testCase.expectedMatch {
+				if match[i+1] != expected {
+					t.Errorf("group %d = %q, want %q", i+1, match[i+1], expected)
+				}
+			}
+		})
+	}
+}
+
+func TestNewPermissionDeniedError(t *testing.T) {
+	testCases := map[string]struct {
+		code    string
+		message string
+
+		expectedAction string
+		expectedScope  string
+	}{
+		"authorization failed": {
+			code:           "AuthorizationFailed",
+			message:        authorizationFailedMessage("Microsoft.Subscription/rename/action", "/subscriptions/"+testSubscriptionId),
+			expectedAction: "Microsoft.Subscription/rename/action",
+			expectedScope:  "/subscriptions/" + testSubscriptionId,
+		},
+		"other message": {
+			code:    "LinkedAuthorizationFailed",
+			message: "The client does not have permission to perform action(s) 'Microsoft.Management/managementGroups/write' on the linked scope(s).",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			permissionDenied := newPermissionDeniedError(newTestResponseError(t, http.StatusForbidden, testCase.code, testCase.message))
+			if permissionDenied.Action != testCase.expectedAction {
+				t.Errorf("Action = %q, want %q", permissionDenied.Action, testCase.expectedAction)
+			}
+			if permissionDenied.Scope != testCase.expectedScope {
+				t.Errorf("Scope = %q, want %q", permissionDenied.Scope, testCase.expectedScope)
+			}
+			if testCase.expectedAction != "" {
+				if permissionDenied.Principal != testPrincipal {
+					t.Errorf("Principal = %q, want %q", permissionDenied.Principal, testPrincipal)
+				}
+				if permissionDenied.PrincipalObjectId != testPrincipalObjectId {
+					t.Errorf("PrincipalObjectId = %q, want %q", permissionDenied.PrincipalObjectId, testPrincipalObjectId)
+				}
+			}
+		})
+	}
+}
+
+func TestPermissionDeniedErrorExplain(t *testing.T) {
+	const moveAction = "Microsoft.Management/managementGroups/subscriptions/write"
+
+	testCases := map[string]struct {
+		action string
+		scope  string
+
+		expectedScope           string
+		expectedAssignmentScope string
+		expectedRole            string
+	}{
+		"pool management group": {
+			action:                  moveAction,
+			scope:                   "/providers/Microsoft.Management/managementGroups/Pool/subscriptions/" + testSubscriptionId,
+			expectedScope:           "the pool management group 'Pool'",
+			expectedAssignmentScope: "/providers/Microsoft.Management/managementGroups/Pool",
+			expectedRole:            "Management Group Contributor",
+		},
+		"source management group": {
+			action:                  moveAction,
+			scope:                   "/providers/Microsoft.Management/managementGroups/team-a/subscriptions/" + testSubscriptionId,
+			expectedScope:           "the source management group 'team-a'",
+			expectedAssignmentScope: "/providers/Microsoft.Management/managementGroups/team-a",
+			expectedRole:            "Management Group Contributor",
+		},
+		"target management group": {
+			action:                  moveAction,
+			scope:                   "/providers/Microsoft.Management/managementGroups/team-b/subscriptions/" + testSubscriptionId,
+			expectedScope:           "the target management group 'team-b'",
+			expectedAssignmentScope: "/providers/Microsoft.Management/managementGroups/team-b",
+			expectedRole:            "Management Group Contributor",
+		},
+		"other management group": {
+			action:                  moveAction,
+			scope:                   "/providers/Microsoft.Management/managementGroups/root",
+			expectedScope:           "the management group 'root'",
+			expectedAssignmentScope: "/providers/Microsoft.Management/managementGroups/root",
+			expectedRole:            "Management Group Contributor",
+		},
+		"subscription": {
+			action:                  "Microsoft.Subscription/rename/action",
+			scope:                   "/subscriptions/" + testSubscriptionId,
+			expectedScope:           "the subscription '" + testSubscriptionId + "'",
+			expectedAssignmentScope: "/subscriptions/" + testSubscriptionId,
+			expectedRole:            "Owner or Contributor",
+		},
+		"role assignment": {
+			action:                  "Microsoft.Authorization/roleAssignments/write",
+			scope:                   "/subscriptions/" + testSubscriptionId + "/providers/Microsoft.Authorization/roleAssignments/x",
+			expectedScope:           "the subscription '" + testSubscriptionId + "'",
+			expectedAssignmentScope: "/subscriptions/" + testSubscriptionId,
+			expectedRole:            "Owner",
+		},
+		"other action": {
+			action:                  "Microsoft.Resources/deployments/write",
+			scope:                   "/tenants/x",
+			expectedScope:           "'/tenants/x'",
+			expectedAssignmentScope: "/tenants/x",
+			expectedRole:            "a role that allows 'Microsoft.Resources/deployments/write'",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			permissionDenied := newPermissionDeniedError(newTestResponseError(t, http.StatusForbidden, "AuthorizationFailed",
+				authorizationFailedMessage(testCase.action, testCase.scope)))
+			permissionDenied.PoolManagementGroupId = "pool"
+			permissionDenied.SourceManagementGroupId = "team-a"
+			permissionDenied.TargetManagementGroupId = "team-b"
+
+			explanation := permissionDenied.explain()
+			expected := "Assign it " + testCase.expectedRole + " on " + testCase.expectedScope + " (scope '" + testCase.expectedAssignmentScope + "')."
+			if !strings.Contains(explanation, expected) {
+				t.Errorf("expected the explanation to contain %q, got:\n%s", expected, explanation)
+			}
+			if !strings.HasSuffix(explanation, "ARM error code: AuthorizationFailed") {
+				t.Errorf("expected the explanation to end with the ARM error code, got:\n%s", explanation)
+			}
+		})
+	}
+}
+
+func TestErrorDiagnosticPermissionDenied(t *testing.T) {
+	testCases := map[string]struct {
+		code    string
+		message string
+
+		expectedDetail string
+	}{
+		"authorization failed": {
+			code:           "AuthorizationFailed",
+			message:        authorizationFailedMessage("Microsoft.Subscription/rename/action", "/subscriptions/"+testSubscriptionId),
+			expectedDetail: "Assign it Owner or Contributor on the subscription '" + testSubscriptionId + "'",
+		},
+		"other message": {
+			code:           "LinkedAuthorizationFailed",
+			message:        "The client does not have permission to perform action(s) 'Microsoft.Management/managementGroups/write' on the linked scope(s).",
+			expectedDetail: "Grant the provider's identity the missing role assignment, see the error above, and apply again.",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := classifyError(newTestResponseError(t, http.StatusForbidden, testCase.code, testCase.message))
+			diagnostic := errorDiagnostic("Error renaming subscription", err)
+			if got := diagnostic.Summary(); got != "Error renaming subscription: permission denied" {
+				t.Errorf("summary = %q, want %q", got, "Error renaming subscription: permission denied")
+			}
+			if !strings.Contains(diagnostic.Detail(), testCase.expectedDetail) {
+				t.Errorf("expected the detail to contain %q, got:\n%s", testCase.expectedDetail, diagnostic.Detail())
+			}
+			if !strings.Contains(diagnostic.Detail(), testCase.message) {
+				t.Errorf("expected the detail to keep ARM's message, got:\n%s", diagnostic.Detail())
+			}
+		})
+	}
+}
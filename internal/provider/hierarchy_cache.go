@@ -0,0 +1,185 @@
+package provider
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/managementgroups/armmanagementgroups"
+)
+
+// hierarchyCache holds the subscriptions of a tenant the provider listed or read during the run, shared by all leases
+// and pools of the tenant. Pools are listed per management group, so leasing never lists the whole tenant.
+type hierarchyCache struct {
+	clientFactory *armmanagementgroups.ClientFactory
+	rateLimiter   *rateLimiter
+
+	mu            sync.Mutex
+	subscriptions map[string]subscriptionState
+	// listed are the IDs of the subscriptions of each listed management group, by lower case management group ID.
+	listed map[string][]string
+	// stale are the subscriptions we moved or renamed since they were read.
+	stale map[string]bool
+}
+
+func newHierarchyCache(clientFactory *armmanagementgroups.ClientFactory, rateLimiter *rateLimiter) *hierarchyCache {
+	return &hierarchyCache{
+		clientFactory: clientFactory,
+		rateLimiter:   rateLimiter,
+		subscriptions: map[string]subscriptionState{},
+		listed:        map[string][]string{},
+		stale:         map[string]bool{},
+	}
+}
+
+// lookup returns the cached state of the subscription. It reports false for subscriptions that are unknown
+// or stale, which the caller has to read from Azure.
+func (c *hierarchyCache) lookup(subscriptionId string) (subscriptionState, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stale[subscriptionId] {
+		return subscriptionState{}, false
+	}
+	subscription, ok := c.subscriptions[subscriptionId]
+	return subscription, ok
+}
+
+// invalidate marks the subscription stale after we changed it, until store records what Azure reports now.
+func (c *hierarchyCache) invalidate(subscriptionId string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stale[subscriptionId] = true
+}
+
+// store records the state of a subscription that was read from Azure.
+func (c *hierarchyCache) store(subscription subscriptionState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subscriptions[subscription.subscriptionId] = subscription
+	delete(c.stale, subscription.subscriptionId)
+}
+
+// listManagementGroup returns the subscriptions placed directly under the management group, ordered by ID.
+// The management group is listed once; subscriptions we changed since are left out, as Azure may not report the change yet.
+// A failed listing is not kept, so the next caller tries again.
+func (c *hierarchyCache) listManagementGroup(ctx context.Context, managementGroupId string) ([]subscriptionState, error) {
+	key := strings.ToLower(managementGroupId)
+	c.mu.Lock()
+	subscriptionIds, ok := c.listed[key]
+	c.mu.Unlock()
+
+	if !ok {
+		listedSubscriptions, err := c.listSubscriptionsUnder(ctx, managementGroupId)
+		if err != nil {
+			return nil, err
+		}
+		subscriptionIds = make([]string, 0, len(listedSubscriptions))
+		for _, subscription := range listedSubscriptions {
+			subscriptionIds = append(subscriptionIds, subscription.subscriptionId)
+		}
+
+		c.mu.Lock()
+		c.remember(listedSubscriptions)
+		c.listed[key] = subscriptionIds
+		c.mu.Unlock()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result := make([]subscriptionState, 0, len(subscriptionIds))
+	for _, id := range subscriptionIds {
+		if subscription, ok := c.subscriptions[id]; ok && !c.stale[id] && strings.EqualFold(subscription.parentManagementGroup, managementGroupId) {
+			result = append(result, subscription)
+		}
+	}
+	return sortedSubscriptions(result), nil
+}
+
+// listTenant lists all subscriptions of the tenant's hierarchy, ordered by ID. Subscriptions we changed since they were
+// read are left out. The listing covers the whole tenant and can take minutes, so it is reserved for discovering leases.
+func (c *hierarchyCache) listTenant(ctx context.Context) ([]subscriptionState, error) {
+	listedSubscriptions, err := c.listSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remember(listedSubscriptions)
+	result := make([]subscriptionState, 0, len(listedSubscriptions))
+	for _, subscription := range listedSubscriptions {
+		if !c.stale[subscription.subscriptionId] {
+			result = append(result, subscription)
+		}
+	}
+	return sortedSubscriptions(result), nil
+}
+
+// remember records listed subscriptions, except the stale ones: the listing may predate our change. c.mu must be held.
+func (c *hierarchyCache) remember(subscriptions []subscriptionState) {
+	for _, subscription := range subscriptions {
+		if !c.stale[subscription.subscriptionId] {
+			c.subscriptions[subscription.subscriptionId] = subscription
+		}
+	}
+}
+
+func sortedSubscriptions(subscriptions []subscriptionState) []subscriptionState {
+	slices.SortFunc(subscriptions, func(a, b subscriptionState) int {
+		return strings.Compare(a.subscriptionId, b.subscriptionId)
+	})
+	return subscriptions
+}
+
+func (c *hierarchyCache) listSubscriptionsUnder(ctx context.Context, managementGroupId string) ([]subscriptionState, error) {
+	var subscriptions []subscriptionState
+	pager := c.clientFactory.NewManagementGroupSubscriptionsClient().NewGetSubscriptionsUnderManagementGroupPager(managementGroupId, nil)
+	for pager.More() {
+		if err := c.rateLimiter.wait(ctx, operationRead); err != nil {
+			return nil, err
+		}
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			// The cache is shared by the pools of the tenant; each classifies the error for its own pool.
+			return nil, err
+		}
+		for _, sub := range page.Value {
+			if sub.Name == nil || sub.Properties == nil || sub.Properties.DisplayName == nil || sub.Properties.Parent == nil || sub.Properties.Parent.ID == nil {
+				continue
+			}
+			subscriptions = append(subscriptions, subscriptionState{
+				subscriptionId:        *sub.Name,
+				displayName:           *sub.Properties.DisplayName,
+				parentManagementGroup: strings.TrimPrefix(*sub.Properties.Parent.ID, managementGroupIdPrefix),
+			})
+		}
+	}
+	return subscriptions, nil
+}
+
+func (c *hierarchyCache) listSubscriptions(ctx context.Context) ([]subscriptionState, error) {
+	var subscriptions []subscriptionState
+	pager := c.clientFactory.NewEntitiesClient().NewListPager(nil)
+	for pager.More() {
+		if err := c.rateLimiter.wait(ctx, operationRead); err != nil {
+			return nil, err
+		}
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			// The cache is shared by the pools of the tenant; each classifies the error for its own pool.
+			return nil, err
+		}
+		for _, entityInfo := range page.Value {
+			if *entityInfo.Type != "/subscriptions" || entityInfo.Properties == nil || entityInfo.Properties.Parent == nil {
+				continue
+			}
+			subscriptions = append(subscriptions, subscriptionState{
+				subscriptionId:        *entityInfo.Name,
+				displayName:           *entityInfo.Properties.DisplayName,
+				parentManagementGroup: strings.TrimPrefix(*entityInfo.Properties.Parent.ID, managementGroupIdPrefix),
+			})
+		}
+	}
+	return subscriptions, nil
+}
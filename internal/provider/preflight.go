@@ -0,0 +1,113 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+)
+
+// requiredPoolActions are the actions a lease needs on the pool management group:
+// listing the pool, moving subscriptions in and out of it and renaming them.
+var requiredPoolActions = []string{
+	"Microsoft.Management/managementGroups/read",
+	"Microsoft.Management/managementGroups/subscriptions/write",
+	"Microsoft.Subscription/rename/action",
+}
+
+// permission is one entry of the Microsoft.Authorization/permissions response.
+type permission struct {
+	Actions    []string `json:"actions"`
+	NotActions []string `json:"notActions"`
+}
+
+// checkToken acquires an ARM token, so broken credentials fail during Configure instead of on the first ARM call.
+func checkToken(ctx context.Context, credential azcore.TokenCredential, clientOptions azcore.ClientOptions) error {
+	audience := clientOptions.Cloud.Services[cloud.ResourceManager].Audience
+	_, err := credential.GetToken(ctx, policy.TokenRequestOptions{
+		Scopes: []string{strings.TrimSuffix(audience, "/") + "/.default"},
+	})
+	return err
+}
+
+// findMissingPoolActions returns the requiredPoolActions the caller is not allowed to perform on the management group.
+func findMissingPoolActions(ctx context.Context, client *arm.Client, managementGroupId string) ([]string, error) {
+	permissions, err := listPermissions(ctx, client, managementGroupIdPrefix+managementGroupId)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, action := range requiredPoolActions {
+		if !isActionPermitted(permissions, action) {
+			missing = append(missing, action)
+		}
+	}
+	return missing, nil
+}
+
+// listPermissions returns the effective permissions of the caller on the given scope.
+func listPermissions(ctx context.Context, client *arm.Client, scope string) ([]permission, error) {
+	req, err := runtime.NewRequest(ctx, http.MethodGet, runtime.JoinPaths(client.Endpoint(), scope, "/providers/Microsoft.Authorization/permissions"))
+	if err != nil {
+		return nil, err
+	}
+	query := req.Raw().URL.Query()
+	query.Set("api-version", "2022-04-01")
+	req.Raw().URL.RawQuery = query.Encode()
+
+	resp, err := client.Pipeline().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if !runtime.HasStatusCode(resp, http.StatusOK) {
+		return nil, runtime.NewResponseError(resp)
+	}
+
+	var result struct {
+		Value []permission `json:"value"`
+	}
+	if err := runtime.UnmarshalAsJSON(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Value, nil
+}
+
+// isActionPermitted reports whether one of the permissions grants the action without excluding it again.
+func isActionPermitted(permissions []permission, action string) bool {
+	for _, p := range permissions {
+		if matchesAnyAction(p.Actions, action) && !matchesAnyAction(p.NotActions, action) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAnyAction matches the action against RBAC action patterns, which are case-insensitive and may contain '*' wildcards.
+func matchesAnyAction(patterns []string, action string) bool {
+	for _, pattern := range patterns {
+		expression := "(?i)^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "$"
+		if matched, _ := regexp.MatchString(expression, action); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// formatMissingActions renders the missing actions of a scope for a diagnostic.
+func formatMissingActions(missing []string, scope string) string {
+	var detail strings.Builder
+	fmt.Fprintf(&detail, "The provider's identity is missing the following permissions on '%s':\n", scope)
+	for _, action := range missing {
+		fmt.Fprintf(&detail, "  - %s\n", action)
+	}
+	detail.WriteString("Grant a role containing these actions on the management group, e.g. \"Management Group Contributor\" together with \"Owner\" or a custom role with Microsoft.Subscription/rename/action.")
+	return detail.String()
+}
@@ -0,0 +1,119 @@
+package provider
+
+import "testing"
+
+func TestMatchesAnyAction(t *testing.T) {
+	testCases := map[string]struct {
+		patterns []string
+		action   string
+		expected bool
+	}{
+		"wildcard": {
+			patterns: []string{"*"},
+			action:   "Microsoft.Subscription/rename/action",
+			expected: true,
+		},
+		"provider wildcard": {
+			patterns: []string{"Microsoft.Management/*"},
+			action:   "Microsoft.Management/managementGroups/subscriptions/write",
+			expected: true,
+		},
+		"provider wildcard of another provider": {
+			patterns: []string{"Microsoft.Management/*"},
+			action:   "Microsoft.Subscription/rename/action",
+		},
+		"inner wildcard": {
+			patterns: []string{"Microsoft.Management/*/write"},
+			action:   "Microsoft.Management/managementGroups/subscriptions/write",
+			expected: true,
+		},
+		"exact action": {
+			patterns: []string{"Microsoft.Authorization/*/read", "Microsoft.Subscription/rename/action"},
+			action:   "Microsoft.Subscription/rename/action",
+			expected: true,
+		},
+		"different case": {
+			patterns: []string{"microsoft.management/managementgroups/*"},
+			action:   "Microsoft.Management/managementGroups/subscriptions/write",
+			expected: true,
+		},
+		"prefix without wildcard": {
+			patterns: []string{"Microsoft.Management/managementGroups"},
+			action:   "Microsoft.Management/managementGroups/subscriptions/write",
+		},
+		"dots are literal": {
+			patterns: []string{"Microsoft.Management/managementGroups/subscriptions.write"},
+			action:   "Microsoft.Management/managementGroups/subscriptionsXwrite",
+		},
+		"no patterns": {
+			action: "Microsoft.Subscription/rename/action",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got := matchesAnyAction(testCase.patterns, testCase.action); got != testCase.expected {
+				t.Errorf("matchesAnyAction(%q, %q) = %t, want %t", testCase.patterns, testCase.action, got, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestIsActionPermitted(t *testing.T) {
+	testCases := map[string]struct {
+		permissions []permission
+		action      string
+		expected    bool
+	}{
+		"owner": {
+			permissions: []permission{{Actions: []string{"*"}}},
+			action:      "Microsoft.Subscription/rename/action",
+			expected:    true,
+		},
+		"contributor": {
+			permissions: []permission{{
+				Actions:    []string{"*"},
+				NotActions: []string{"Microsoft.Authorization/*/Delete", "Microsoft.Authorization/*/Write"},
+			}},
+			action:   "Microsoft.Management/managementGroups/subscriptions/write",
+			expected: true,
+		},
+		"excluded by not actions": {
+			permissions: []permission{{
+				Actions:    []string{"*"},
+				NotActions: []string{"Microsoft.Authorization/*/Delete", "Microsoft.Authorization/*/Write"},
+			}},
+			action: "Microsoft.Authorization/roleAssignments/write",
+		},
+		"excluded by not actions of a different case": {
+			permissions: []permission{{
+				Actions:    []string{"Microsoft.Management/*"},
+				NotActions: []string{"microsoft.management/managementgroups/subscriptions/write"},
+			}},
+			action: "Microsoft.Management/managementGroups/subscriptions/write",
+		},
+		"excluded in one role, granted by another": {
+			permissions: []permission{
+				{Actions: []string{"*"}, NotActions: []string{"Microsoft.Subscription/*"}},
+				{Actions: []string{"Microsoft.Subscription/rename/action"}},
+			},
+			action:   "Microsoft.Subscription/rename/action",
+			expected: true,
+		},
+		"not granted": {
+			permissions: []permission{{Actions: []string{"Microsoft.Management/*"}}},
+			action:      "Microsoft.Subscription/rename/action",
+		},
+		"no permissions": {
+			action: "Microsoft.Subscription/rename/action",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got := isActionPermitted(testCase.permissions, testCase.action); got != testCase.expected {
+				t.Errorf("isActionPermitted(%v, %q) = %t, want %t", testCase.permissions, testCase.action, got, testCase.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// ARM serves reads from caches that lag behind moves and renames. These settings bound how long
+// we wait for our own changes to show up, and how long Read keeps trusting them afterwards.
+const (
+	propagationTimeout      = 5 * time.Minute
+	propagationInitialDelay = 2 * time.Second
+	propagationMaxDelay     = 30 * time.Second
+	propagationWindow       = 15 * time.Minute
+)
+
+// pendingLeaseChangePrivateKey holds the pendingLeaseChange of a lease in the resource's private state.
+const pendingLeaseChangePrivateKey = "pending_change"
+
+// pendingLeaseChange is the last change we made to a leased subscription.
+// Until it is observed, or the propagation window has passed, Read keeps the values we wrote.
+type pendingLeaseChange struct {
+	ManagementGroupName string    `json:"management_group_name"`
+	DisplayName         string    `json:"display_name"`
+	Until               time.Time `json:"until"`
+}
+
+type privateDataSetter interface {
+	SetKey(ctx context.Context, key string, value []byte) diag.Diagnostics
+}
+
+// WaitForSubscription polls the management group and the subscription endpoints until both report the
+// subscription under the management group with the display name, so later reads don't see stale data.
+func (b *BaseClient) WaitForSubscription(ctx context.Context, subscriptionId string, managementGroupId string, displayName string) error {
+	ctx, cancel := context.WithTimeout(ctx, propagationTimeout)
+	defer cancel()
+
+	delay := propagationInitialDelay
+	for {
+		propagated, err := b.isSubscriptionPropagated(ctx, subscriptionId, managementGroupId, displayName)
+		if err != nil {
+			return err
+		}
+		if propagated {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("subscription '%s' did not show up as '%s' under ManagementGroup '%s' in time: %w", subscriptionId, displayName, managementGroupId, ctx.Err())
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, propagationMaxDelay)
+	}
+}
+
+func (b *BaseClient) isSubscriptionPropagated(ctx context.Context, subscriptionId string, managementGroupId string, displayName string) (bool, error) {
+	underManagementGroup, err := b.GetSubscription(ctx, managementGroupId, subscriptionId)
+	if isNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if underManagementGroup.Properties == nil || underManagementGroup.Properties.DisplayName == nil || *underManagementGroup.Properties.DisplayName != displayName {
+		return false, nil
+	}
+
+	if err := b.rateLimiter.wait(ctx, operationRead); err != nil {
+		return false, err
+	}
+	subscription, err := b.subscriptionClientFactory.NewSubscriptionsClient().Get(ctx, subscriptionId, nil)
+	if err != nil {
+		return false, err
+	}
+	return subscription.DisplayName != nil && *subscription.DisplayName == displayName, nil
+}
+
+// isNotFound reports whether ARM answered the request with 404.
+func isNotFound(err error) bool {
+	var responseError *azcore.ResponseError
+	return errors.As(err, &responseError) && responseError.StatusCode == http.StatusNotFound
+}
+
+// setPendingLeaseChange remembers the change we just made, see pendingLeaseChange.
+func setPendingLeaseChange(ctx context.Context, private privateDataSetter, managementGroupName string, displayName string) diag.Diagnostics {
+	raw, err := json.Marshal(pendingLeaseChange{
+		ManagementGroupName: managementGroupName,
+		DisplayName:         displayName,
+		Until:               time.Now().Add(propagationWindow),
+	})
+	if err != nil {
+		var diags diag.Diagnostics
+		diags.AddError("Error encoding pending lease change", err.Error())
+		return diags
+	}
+	return private.SetKey(ctx, pendingLeaseChangePrivateKey, raw)
+}
+
+// getPendingLeaseChange returns the change that may not be visible yet, or nil once the propagation window has passed.
+func getPendingLeaseChange(ctx context.Context, private privateDataGetter) (*pendingLeaseChange, diag.Diagnostics) {
+	raw, diags := private.GetKey(ctx, pendingLeaseChangePrivateKey)
+	if diags.HasError() || raw == nil {
+		return nil, diags
+	}
+
+	var change pendingLeaseChange
+	if err := json.Unmarshal(raw, &change); err != nil {
+		diags.AddError("Error decoding pending lease change", err.Error())
+		return nil, diags
+	}
+	if time.Now().After(change.Until) {
+		return nil, diags
+	}
+	return &change, diags
+}
+
+// isObservedIn reports whether the parent and display name read from Azure already reflect the change.
+func (c *pendingLeaseChange) isObservedIn(parentManagementGroup string, displayName string) bool {
+	return strings.EqualFold(parentManagementGroup, c.ManagementGroupName) && displayName == c.DisplayName
+}
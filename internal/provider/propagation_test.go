@@ -0,0 +1,137 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// testPrivateData is an in-memory private state, standing in for the framework's.
+type testPrivateData map[string][]byte
+
+func (p testPrivateData) GetKey(_ context.Context, key string) ([]byte, diag.Diagnostics) {
+	return p[key], nil
+}
+
+func (p testPrivateData) SetKey(_ context.Context, key string, value []byte) diag.Diagnostics {
+	p[key] = value
+	return nil
+}
+
+// withPendingLeaseChange returns private state holding the change.
+func withPendingLeaseChange(t *testing.T, change pendingLeaseChange) testPrivateData {
+	t.Helper()
+	raw, err := json.Marshal(change)
+	if err != nil {
+		t.Fatalf("unexpected error encoding the pending lease change: %v", err)
+	}
+	return testPrivateData{pendingLeaseChangePrivateKey: raw}
+}
+
+func TestGetPendingLeaseChange(t *testing.T) {
+	testCases := map[string]struct {
+		private testPrivateData
+
+		expectedChange bool
+		expectError    bool
+	}{
+		"no change": {
+			private: testPrivateData{},
+		},
+		"within the propagation window": {
+			private:        withPendingLeaseChange(t, pendingLeaseChange{ManagementGroupName: "team-a", DisplayName: "lease", Until: time.Now().Add(time.Minute)}),
+			expectedChange: true,
+		},
+		"after the propagation window": {
+			private: withPendingLeaseChange(t, pendingLeaseChange{ManagementGroupName: "team-a", DisplayName: "lease", Until: time.Now().Add(-time.Second)}),
+		},
+		"invalid private state": {
+			private:     testPrivateData{pendingLeaseChangePrivateKey: []byte("{")},
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			change, diags := getPendingLeaseChange(context.Background(), testCase.private)
+			if testCase.expectError {
+				if !diags.HasError() {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			if (change != nil) != testCase.expectedChange {
+				t.Fatalf("change = %+v, want a change: %t", change, testCase.expectedChange)
+			}
+		})
+	}
+}
+
+func TestSetPendingLeaseChange(t *testing.T) {
+	ctx := context.Background()
+	private := testPrivateData{}
+	if diags := setPendingLeaseChange(ctx, private, "team-a", "lease"); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	change, diags := getPendingLeaseChange(ctx, private)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if change == nil {
+		t.Fatal("expected the change to be pending")
+	}
+	if change.ManagementGroupName != "team-a" || change.DisplayName != "lease" {
+		t.Errorf("change = %+v, want management group 'team-a' and display name 'lease'", change)
+	}
+	if until := time.Until(change.Until); until <= 0 || until > propagationWindow {
+		t.Errorf("change is pending for %s, want at most %s", until, propagationWindow)
+	}
+}
+
+func TestPendingLeaseChangeIsObservedIn(t *testing.T) {
+	change := pendingLeaseChange{ManagementGroupName: "Team-A", DisplayName: "lease-1"}
+
+	testCases := map[string]struct {
+		parentManagementGroup string
+		displayName           string
+		expected              bool
+	}{
+		"observed": {
+			parentManagementGroup: "Team-A",
+			displayName:           "lease-1",
+			expected:              true,
+		},
+		"management group of a different case": {
+			parentManagementGroup: "team-a",
+			displayName:           "lease-1",
+			expected:              true,
+		},
+		"display name of a different case": {
+			parentManagementGroup: "Team-A",
+			displayName:           "Lease-1",
+		},
+		"not moved yet": {
+			parentManagementGroup: "pool",
+			displayName:           "lease-1",
+		},
+		"not renamed yet": {
+			parentManagementGroup: "Team-A",
+			displayName:           "pool-1",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got := change.isObservedIn(testCase.parentManagementGroup, testCase.displayName); got != testCase.expected {
+				t.Errorf("isObservedIn(%q, %q) = %t, want %t", testCase.parentManagementGroup, testCase.displayName, got, testCase.expected)
+			}
+		})
+	}
+}
@@ -2,13 +2,20 @@ package provider
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/managementgroups/armmanagementgroups"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/subscription/armsubscription"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/list"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
@@ -18,7 +25,9 @@ import (
 
 // Ensure the implementation satisfies the expected interfaces.
 var (
-	_ provider.Provider = &azurecnProvider{}
+	_ provider.Provider                       = &azurecnProvider{}
+	_ provider.ProviderWithEphemeralResources = &azurecnProvider{}
+	_ provider.ProviderWithListResources      = &azurecnProvider{}
 )
 
 // New is a helper function to simplify provider server and testing implementation.
@@ -39,11 +48,52 @@ type azurecnProvider struct {
 }
 
 type azurecnProviderModel struct {
-	TenantId                   types.String `tfsdk:"tenant_id"`
-	ClientId                   types.String `tfsdk:"client_id"`
-	ClientSecret               types.String `tfsdk:"client_secret"`
-	PoolManagementGroup        types.String `tfsdk:"subscription_pool_management_group"`
-	PoolSubscriptionNamePrefix types.String `tfsdk:"subscription_pool_name_prefix"`
+	Environment                       types.String           `tfsdk:"environment"`
+	ResourceManagerEndpoint           types.String           `tfsdk:"resource_manager_endpoint"`
+	AuthorityHost                     types.String           `tfsdk:"authority_host"`
+	InsecureSkipTlsVerify             types.Bool             `tfsdk:"insecure_skip_tls_verify"`
+	CaCertificatePath                 types.String           `tfsdk:"ca_certificate_path"`
+	TenantId                          types.String           `tfsdk:"tenant_id"`
+	ClientId                          types.String           `tfsdk:"client_id"`
+	ClientSecret                      types.String           `tfsdk:"client_secret"`
+	ClientSecretFilePath              types.String           `tfsdk:"client_secret_file_path"`
+	ClientCertificate                 types.String           `tfsdk:"client_certificate"`
+	ClientCertificatePath             types.String           `tfsdk:"client_certificate_path"`
+	ClientCertificatePassword         types.String           `tfsdk:"client_certificate_password"`
+	ClientCertificatePasswordFilePath types.String           `tfsdk:"client_certificate_password_file_path"`
+	UseCli                            types.Bool             `tfsdk:"use_cli"`
+	UseMsi                            types.Bool             `tfsdk:"use_msi"`
+	UseOidc                           types.Bool             `tfsdk:"use_oidc"`
+	OidcToken                         types.String           `tfsdk:"oidc_token"`
+	OidcTokenFilePath                 types.String           `tfsdk:"oidc_token_file_path"`
+	OidcRequestUrl                    types.String           `tfsdk:"oidc_request_url"`
+	OidcRequestToken                  types.String           `tfsdk:"oidc_request_token"`
+	SkipCredentialsValidation         types.Bool             `tfsdk:"skip_credentials_validation"`
+	PoolManagementGroup               types.String           `tfsdk:"subscription_pool_management_group"`
+	PoolSubscriptionNamePrefix        types.String           `tfsdk:"subscription_pool_name_prefix"`
+	Pools                             types.Map              `tfsdk:"pools"`
+	Retry                             *azurecnRetryModel     `tfsdk:"retry"`
+	RateLimit                         *azurecnRateLimitModel `tfsdk:"rate_limit"`
+}
+
+// azurecnPoolModel describes an additional subscription pool. Credential settings
+// that are not set are inherited from the provider configuration.
+type azurecnPoolModel struct {
+	ManagementGroup                   types.String `tfsdk:"management_group"`
+	NamePrefix                        types.String `tfsdk:"name_prefix"`
+	TenantId                          types.String `tfsdk:"tenant_id"`
+	ClientId                          types.String `tfsdk:"client_id"`
+	ClientSecret                      types.String `tfsdk:"client_secret"`
+	ClientSecretFilePath              types.String `tfsdk:"client_secret_file_path"`
+	ClientCertificate                 types.String `tfsdk:"client_certificate"`
+	ClientCertificatePath             types.String `tfsdk:"client_certificate_path"`
+	ClientCertificatePassword         types.String `tfsdk:"client_certificate_password"`
+	ClientCertificatePasswordFilePath types.String `tfsdk:"client_certificate_password_file_path"`
+	UseCli                            types.Bool   `tfsdk:"use_cli"`
+	UseMsi                            types.Bool   `tfsdk:"use_msi"`
+	UseOidc                           types.Bool   `tfsdk:"use_oidc"`
+	OidcToken                         types.String `tfsdk:"oidc_token"`
+	OidcTokenFilePath                 types.String `tfsdk:"oidc_token_file_path"`
 }
 
 // Metadata returns the provider type name.
@@ -56,6 +106,26 @@ func (p *azurecnProvider) Metadata(_ context.Context, _ provider.MetadataRequest
 func (p *azurecnProvider) Schema(_ context.Context, _ provider.SchemaRequest, resp *provider.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Attributes: map[string]schema.Attribute{
+			"environment": schema.StringAttribute{
+				Description: "The Azure cloud to use: public, usgovernment or china. Defaults to public. Can also be set with the ARM_ENVIRONMENT environment variable.",
+				Optional:    true,
+			},
+			"resource_manager_endpoint": schema.StringAttribute{
+				Description: "Overrides the Azure Resource Manager endpoint of the environment, e.g. to run against a local stand-in. Can also be set with the ARM_RESOURCE_MANAGER_ENDPOINT environment variable.",
+				Optional:    true,
+			},
+			"authority_host": schema.StringAttribute{
+				Description: "Overrides the Entra ID authority host of the environment. Can also be set with the ARM_AUTHORITY_HOST environment variable.",
+				Optional:    true,
+			},
+			"insecure_skip_tls_verify": schema.BoolAttribute{
+				Description: "Skips TLS certificate verification. Only meant for local stand-ins of Azure. Can also be set with the ARM_INSECURE_SKIP_TLS_VERIFY environment variable.",
+				Optional:    true,
+			},
+			"ca_certificate_path": schema.StringAttribute{
+				Description: "Path to a PEM encoded CA certificate trusted in addition to the system roots. Can also be set with the ARM_CA_CERTIFICATE_PATH environment variable.",
+				Optional:    true,
+			},
 			"tenant_id": schema.StringAttribute{
 				Description: "todo: i just want to finish the initial publication",
 				Optional:    true,
@@ -69,6 +139,62 @@ func (p *azurecnProvider) Schema(_ context.Context, _ provider.SchemaRequest, re
 				Optional:    true,
 				Sensitive:   true,
 			},
+			"client_secret_file_path": schema.StringAttribute{
+				Description: "Path to a file containing the client secret, e.g. a mounted secret of the CI runner. Can also be set with the ARM_CLIENT_SECRET_FILE_PATH environment variable.",
+				Optional:    true,
+			},
+			"client_certificate": schema.StringAttribute{
+				Description: "Base64 encoded PKCS#12 or PEM client certificate including the private key. Can also be set with the ARM_CLIENT_CERTIFICATE environment variable.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"client_certificate_path": schema.StringAttribute{
+				Description: "Path to a PKCS#12 or PEM client certificate including the private key. Can also be set with the ARM_CLIENT_CERTIFICATE_PATH environment variable.",
+				Optional:    true,
+			},
+			"client_certificate_password": schema.StringAttribute{
+				Description: "Password of the client certificate, if any. Can also be set with the ARM_CLIENT_CERTIFICATE_PASSWORD environment variable.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"client_certificate_password_file_path": schema.StringAttribute{
+				Description: "Path to a file containing the password of the client certificate. Can also be set with the ARM_CLIENT_CERTIFICATE_PASSWORD_FILE_PATH environment variable.",
+				Optional:    true,
+			},
+			"use_cli": schema.BoolAttribute{
+				Description: "Authenticate with the Azure CLI login. Can also be set with the ARM_USE_CLI environment variable.",
+				Optional:    true,
+			},
+			"use_msi": schema.BoolAttribute{
+				Description: "Authenticate with a managed identity; client_id selects a user-assigned identity. Can also be set with the ARM_USE_MSI environment variable.",
+				Optional:    true,
+			},
+			"use_oidc": schema.BoolAttribute{
+				Description: "Authenticate with an OIDC ID token via workload identity federation. Can also be set with the ARM_USE_OIDC environment variable.",
+				Optional:    true,
+			},
+			"oidc_token": schema.StringAttribute{
+				Description: "The OIDC ID token, e.g. a GitLab CI id_token. Can also be set with the ARM_OIDC_TOKEN environment variable.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"oidc_token_file_path": schema.StringAttribute{
+				Description: "Path to a file containing the OIDC ID token. Can also be set with the ARM_OIDC_TOKEN_FILE_PATH environment variable.",
+				Optional:    true,
+			},
+			"oidc_request_url": schema.StringAttribute{
+				Description: "The URL to request an OIDC ID token from, as provided by GitHub Actions. Can also be set with the ARM_OIDC_REQUEST_URL or ACTIONS_ID_TOKEN_REQUEST_URL environment variables.",
+				Optional:    true,
+			},
+			"oidc_request_token": schema.StringAttribute{
+				Description: "The bearer token for oidc_request_url. Can also be set with the ARM_OIDC_REQUEST_TOKEN or ACTIONS_ID_TOKEN_REQUEST_TOKEN environment variables.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"skip_credentials_validation": schema.BoolAttribute{
+				Description: "Skips acquiring a token when the provider first connects to a tenant, and checking the permissions on a pool management group before its first lease. Can also be set with the ARM_SKIP_CREDENTIALS_VALIDATION environment variable.",
+				Optional:    true,
+			},
 			"subscription_pool_management_group": schema.StringAttribute{
 				Description: "todo: i just want to finish the initial publication",
 				Optional:    true,
@@ -77,6 +203,116 @@ func (p *azurecnProvider) Schema(_ context.Context, _ provider.SchemaRequest, re
 				Description: "todo: i just want to finish the initial publication",
 				Optional:    true,
 			},
+			"pools": schema.MapNestedAttribute{
+				Description: "Additional subscription pools by name, e.g. in other tenants. Leases select one with their pool attribute. " +
+					"Credential settings that are not set are inherited from the provider configuration; pools in the same tenant must use the same credentials.",
+				Optional: true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"management_group": schema.StringAttribute{
+							Description: "The management group holding the free subscriptions of the pool.",
+							Required:    true,
+						},
+						"name_prefix": schema.StringAttribute{
+							Description: "The display name prefix of free subscriptions. Defaults to subscription_pool_name_prefix.",
+							Optional:    true,
+						},
+						"tenant_id": schema.StringAttribute{
+							Optional: true,
+						},
+						"client_id": schema.StringAttribute{
+							Optional: true,
+						},
+						"client_secret": schema.StringAttribute{
+							Optional:  true,
+							Sensitive: true,
+						},
+						"client_secret_file_path": schema.StringAttribute{
+							Optional: true,
+						},
+						"client_certificate": schema.StringAttribute{
+							Optional:  true,
+							Sensitive: true,
+						},
+						"client_certificate_path": schema.StringAttribute{
+							Optional: true,
+						},
+						"client_certificate_password": schema.StringAttribute{
+							Optional:  true,
+							Sensitive: true,
+						},
+						"client_certificate_password_file_path": schema.StringAttribute{
+							Optional: true,
+						},
+						"use_cli": schema.BoolAttribute{
+							Optional: true,
+						},
+						"use_msi": schema.BoolAttribute{
+							Optional: true,
+						},
+						"use_oidc": schema.BoolAttribute{
+							Optional: true,
+						},
+						"oidc_token": schema.StringAttribute{
+							Optional:  true,
+							Sensitive: true,
+						},
+						"oidc_token_file_path": schema.StringAttribute{
+							Optional: true,
+						},
+					},
+				},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"retry": schema.SingleNestedBlock{
+				Description: "Controls how requests to Azure are retried, e.g. when renames are throttled. A Retry-After header sent by Azure takes precedence over the delays; if it asks for more than max_retry_delay, the request fails instead.",
+				Attributes: map[string]schema.Attribute{
+					"max_retries": schema.Int64Attribute{
+						Description: "How often a failed request is retried. Defaults to 3; 0 disables retries.",
+						Optional:    true,
+					},
+					"retry_delay": schema.StringAttribute{
+						Description: "The base delay between retries, e.g. \"4s\", growing exponentially with every retry. Defaults to 800ms.",
+						Optional:    true,
+					},
+					"max_retry_delay": schema.StringAttribute{
+						Description: "The maximum delay between retries, e.g. \"2m\". Defaults to 60s.",
+						Optional:    true,
+					},
+					"status_codes": schema.ListAttribute{
+						Description: "The HTTP status codes that are retried. Defaults to 408, 429, 500, 502, 503 and 504.",
+						ElementType: types.Int64Type,
+						Optional:    true,
+					},
+				},
+			},
+			"rate_limit": schema.SingleNestedBlock{
+				Description: "Limits the rate of requests the provider sends to Azure per tenant, so many parallel leases don't trip Azure's throttling. " +
+					"Moves default to 2 requests per second with a burst of 5, renames to 1 request per second with a burst of 2; reads are not limited by default.",
+				Blocks: map[string]schema.Block{
+					"read":   operationRateLimitBlock("reads of subscriptions and the management group hierarchy"),
+					"move":   operationRateLimitBlock("moves of subscriptions between management groups"),
+					"rename": operationRateLimitBlock("renames of subscriptions"),
+				},
+			},
+		},
+	}
+}
+
+// operationRateLimitBlock is the rate limit block of one operation class, see azurecnOperationRateLimitModel.
+func operationRateLimitBlock(operations string) schema.SingleNestedBlock {
+	return schema.SingleNestedBlock{
+		Description: "Limits the " + operations + ".",
+		Attributes: map[string]schema.Attribute{
+			"requests_per_second": schema.Float64Attribute{
+				Description: "The sustained number of requests per second, e.g. 0.5 for one request every two seconds.",
+				Optional:    true,
+			},
+			"burst": schema.Int64Attribute{
+				Description: "How many requests may be sent at once before the rate applies. Defaults to 1.",
+				Optional:    true,
+			},
 		},
 	}
 }
@@ -91,9 +327,63 @@ func (p *azurecnProvider) Configure(ctx context.Context, req provider.ConfigureR
 		return
 	}
 
+	// Terraform 1.9+ can defer everything that depends on this provider until the
+	// unknown values are known, e.g. credentials of a service principal created in the same plan.
+	if req.ClientCapabilities.DeferralAllowed && !req.Config.Raw.IsFullyKnown() {
+		resp.Deferred = &provider.Deferred{
+			Reason: provider.DeferredReasonProviderConfigUnknown,
+		}
+		return
+	}
+
 	// If practitioner provided a configuration value for any of the
 	// attributes, it must be a known value.
 
+	if config.Environment.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("environment"),
+			"Unknown Azure environment",
+			"The provider cannot create the Azure API client as there is an unknown configuration value for the Azure environment. "+
+				"Either target apply the source of the value first, set the value statically in the configuration, or use the ARM_ENVIRONMENT environment variable.",
+		)
+	}
+
+	if config.ResourceManagerEndpoint.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("resource_manager_endpoint"),
+			"Unknown resource_manager_endpoint",
+			"The provider cannot create the Azure API client as there is an unknown configuration value for resource_manager_endpoint. "+
+				"Either target apply the source of the value first, set the value statically in the configuration, or use the ARM_RESOURCE_MANAGER_ENDPOINT environment variable.",
+		)
+	}
+
+	if config.AuthorityHost.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("authority_host"),
+			"Unknown authority_host",
+			"The provider cannot create the Azure API client as there is an unknown configuration value for authority_host. "+
+				"Either target apply the source of the value first, set the value statically in the configuration, or use the ARM_AUTHORITY_HOST environment variable.",
+		)
+	}
+
+	if config.InsecureSkipTlsVerify.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("insecure_skip_tls_verify"),
+			"Unknown insecure_skip_tls_verify",
+			"The provider cannot create the Azure API client as there is an unknown configuration value for insecure_skip_tls_verify. "+
+				"Either target apply the source of the value first, set the value statically in the configuration, or use the ARM_INSECURE_SKIP_TLS_VERIFY environment variable.",
+		)
+	}
+
+	if config.CaCertificatePath.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("ca_certificate_path"),
+			"Unknown ca_certificate_path",
+			"The provider cannot create the Azure API client as there is an unknown configuration value for ca_certificate_path. "+
+				"Either target apply the source of the value first, set the value statically in the configuration, or use the ARM_CA_CERTIFICATE_PATH environment variable.",
+		)
+	}
+
 	if config.TenantId.IsUnknown() {
 		resp.Diagnostics.AddAttributeError(
 			path.Root("tenant_id"),
@@ -121,7 +411,124 @@ func (p *azurecnProvider) Configure(ctx context.Context, req provider.ConfigureR
 		)
 	}
 
-	if config.ClientSecret.IsUnknown() {
+	if config.ClientSecretFilePath.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("client_secret_file_path"),
+			"Unknown Azure API client_secret_file_path",
+			"The provider cannot create the Azure API client as there is an unknown configuration value for the Azure API client_secret_file_path. "+
+				"Either target apply the source of the value first, set the value statically in the configuration, or use the ARM_CLIENT_SECRET_FILE_PATH environment variable.",
+		)
+	}
+
+	if config.ClientCertificate.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("client_certificate"),
+			"Unknown Azure API client_certificate",
+			"The provider cannot create the Azure API client as there is an unknown configuration value for the Azure API client_certificate. "+
+				"Either target apply the source of the value first, set the value statically in the configuration, or use the ARM_CLIENT_CERTIFICATE environment variable.",
+		)
+	}
+
+	if config.ClientCertificatePath.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("client_certificate_path"),
+			"Unknown Azure API client_certificate_path",
+			"The provider cannot create the Azure API client as there is an unknown configuration value for the Azure API client_certificate_path. "+
+				"Either target apply the source of the value first, set the value statically in the configuration, or use the ARM_CLIENT_CERTIFICATE_PATH environment variable.",
+		)
+	}
+
+	if config.ClientCertificatePassword.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("client_certificate_password"),
+			"Unknown Azure API client_certificate_password",
+			"The provider cannot create the Azure API client as there is an unknown configuration value for the Azure API client_certificate_password. "+
+				"Either target apply the source of the value first, set the value statically in the configuration, or use the ARM_CLIENT_CERTIFICATE_PASSWORD environment variable.",
+		)
+	}
+
+	if config.ClientCertificatePasswordFilePath.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("client_certificate_password_file_path"),
+			"Unknown Azure API client_certificate_password_file_path",
+			"The provider cannot create the Azure API client as there is an unknown configuration value for the Azure API client_certificate_password_file_path. "+
+				"Either target apply the source of the value first, set the value statically in the configuration, or use the ARM_CLIENT_CERTIFICATE_PASSWORD_FILE_PATH environment variable.",
+		)
+	}
+
+	if config.UseCli.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("use_cli"),
+			"Unknown use_cli",
+			"The provider cannot choose the authentication method as there is an unknown configuration value for use_cli. "+
+				"Either target apply the source of the value first, set the value statically in the configuration, or use the ARM_USE_CLI environment variable.",
+		)
+	}
+
+	if config.UseMsi.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("use_msi"),
+			"Unknown use_msi",
+			"The provider cannot choose the authentication method as there is an unknown configuration value for use_msi. "+
+				"Either target apply the source of the value first, set the value statically in the configuration, or use the ARM_USE_MSI environment variable.",
+		)
+	}
+
+	if config.UseOidc.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("use_oidc"),
+			"Unknown use_oidc",
+			"The provider cannot choose the authentication method as there is an unknown configuration value for use_oidc. "+
+				"Either target apply the source of the value first, set the value statically in the configuration, or use the ARM_USE_OIDC environment variable.",
+		)
+	}
+
+	if config.OidcToken.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("oidc_token"),
+			"Unknown oidc_token",
+			"The provider cannot create the Azure API client as there is an unknown configuration value for oidc_token. "+
+				"Either target apply the source of the value first, set the value statically in the configuration, or use the ARM_OIDC_TOKEN environment variable.",
+		)
+	}
+
+	if config.OidcTokenFilePath.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("oidc_token_file_path"),
+			"Unknown oidc_token_file_path",
+			"The provider cannot create the Azure API client as there is an unknown configuration value for oidc_token_file_path. "+
+				"Either target apply the source of the value first, set the value statically in the configuration, or use the ARM_OIDC_TOKEN_FILE_PATH environment variable.",
+		)
+	}
+
+	if config.OidcRequestUrl.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("oidc_request_url"),
+			"Unknown oidc_request_url",
+			"The provider cannot create the Azure API client as there is an unknown configuration value for oidc_request_url. "+
+				"Either target apply the source of the value first, set the value statically in the configuration, or use the ARM_OIDC_REQUEST_URL environment variable.",
+		)
+	}
+
+	if config.OidcRequestToken.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("oidc_request_token"),
+			"Unknown oidc_request_token",
+			"The provider cannot create the Azure API client as there is an unknown configuration value for oidc_request_token. "+
+				"Either target apply the source of the value first, set the value statically in the configuration, or use the ARM_OIDC_REQUEST_TOKEN environment variable.",
+		)
+	}
+
+	if config.SkipCredentialsValidation.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("skip_credentials_validation"),
+			"Unknown skip_credentials_validation",
+			"The provider cannot decide whether to validate the credentials as there is an unknown configuration value for skip_credentials_validation. "+
+				"Either target apply the source of the value first, set the value statically in the configuration, or use the ARM_SKIP_CREDENTIALS_VALIDATION environment variable.",
+		)
+	}
+
+	if config.PoolManagementGroup.IsUnknown() {
 		resp.Diagnostics.AddAttributeError(
 			path.Root("subscription_pool_management_group"),
 			"Unknown subscription_pool_management_group",
@@ -129,7 +536,7 @@ func (p *azurecnProvider) Configure(ctx context.Context, req provider.ConfigureR
 		)
 	}
 
-	if config.ClientSecret.IsUnknown() {
+	if config.PoolSubscriptionNamePrefix.IsUnknown() {
 		resp.Diagnostics.AddAttributeError(
 			path.Root("subscription_pool_name_prefix"),
 			"Unknown subscription_pool_name_prefix",
@@ -137,6 +544,32 @@ func (p *azurecnProvider) Configure(ctx context.Context, req provider.ConfigureR
 		)
 	}
 
+	if config.Retry != nil && config.Retry.isUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("retry"),
+			"Unknown retry settings",
+			"The provider cannot create the Azure API client as there is an unknown configuration value in the retry block. "+
+				"Either target apply the source of the value first or set the value statically in the configuration.",
+		)
+	}
+
+	if config.RateLimit != nil && config.RateLimit.isUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("rate_limit"),
+			"Unknown rate limit settings",
+			"The provider cannot create the Azure API client as there is an unknown configuration value in the rate_limit block. "+
+				"Either target apply the source of the value first or set the value statically in the configuration.",
+		)
+	}
+
+	if config.Pools.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("pools"),
+			"Unknown pools",
+			"We require the subscription pools on provider configuration to avoid race conditions during the apply.",
+		)
+	}
+
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -144,12 +577,54 @@ func (p *azurecnProvider) Configure(ctx context.Context, req provider.ConfigureR
 	// Default values to environment variables, but override
 	// with Terraform configuration value if set.
 
+	environment := os.Getenv("ARM_ENVIRONMENT")
+	resourceManagerEndpoint := os.Getenv("ARM_RESOURCE_MANAGER_ENDPOINT")
+	authorityHost := os.Getenv("ARM_AUTHORITY_HOST")
+	insecureSkipTlsVerify, _ := strconv.ParseBool(os.Getenv("ARM_INSECURE_SKIP_TLS_VERIFY"))
+	caCertificatePath := os.Getenv("ARM_CA_CERTIFICATE_PATH")
 	tenantId := os.Getenv("ARM_TENANT_ID")
 	clientId := os.Getenv("ARM_CLIENT_ID")
 	clientSecret := os.Getenv("ARM_CLIENT_SECRET")
+	clientSecretFilePath := os.Getenv("ARM_CLIENT_SECRET_FILE_PATH")
+	clientCertificate := os.Getenv("ARM_CLIENT_CERTIFICATE")
+	clientCertificatePath := os.Getenv("ARM_CLIENT_CERTIFICATE_PATH")
+	clientCertificatePassword := os.Getenv("ARM_CLIENT_CERTIFICATE_PASSWORD")
+	clientCertificatePasswordFilePath := os.Getenv("ARM_CLIENT_CERTIFICATE_PASSWORD_FILE_PATH")
+	useCli, _ := strconv.ParseBool(os.Getenv("ARM_USE_CLI"))
+	useMsi, _ := strconv.ParseBool(os.Getenv("ARM_USE_MSI"))
+	useOidc, _ := strconv.ParseBool(os.Getenv("ARM_USE_OIDC"))
+	oidcToken := os.Getenv("ARM_OIDC_TOKEN")
+	oidcTokenFilePath := os.Getenv("ARM_OIDC_TOKEN_FILE_PATH")
+	oidcRequestUrl := getEnvWithFallback("ARM_OIDC_REQUEST_URL", "ACTIONS_ID_TOKEN_REQUEST_URL")
+	oidcRequestToken := getEnvWithFallback("ARM_OIDC_REQUEST_TOKEN", "ACTIONS_ID_TOKEN_REQUEST_TOKEN")
+	skipCredentialsValidation, _ := strconv.ParseBool(os.Getenv("ARM_SKIP_CREDENTIALS_VALIDATION"))
 	poolManagementGroupId := "Crossnative"
 	poolSubscriptionPrefix := "Azure_Subscription_Crossnative_Pool_"
 
+	if !config.Environment.IsNull() {
+		environment = config.Environment.ValueString()
+	}
+
+	if environment == "" {
+		environment = "public"
+	}
+
+	if !config.ResourceManagerEndpoint.IsNull() {
+		resourceManagerEndpoint = config.ResourceManagerEndpoint.ValueString()
+	}
+
+	if !config.AuthorityHost.IsNull() {
+		authorityHost = config.AuthorityHost.ValueString()
+	}
+
+	if !config.InsecureSkipTlsVerify.IsNull() {
+		insecureSkipTlsVerify = config.InsecureSkipTlsVerify.ValueBool()
+	}
+
+	if !config.CaCertificatePath.IsNull() {
+		caCertificatePath = config.CaCertificatePath.ValueString()
+	}
+
 	if !config.TenantId.IsNull() {
 		tenantId = config.TenantId.ValueString()
 	}
@@ -162,6 +637,58 @@ func (p *azurecnProvider) Configure(ctx context.Context, req provider.ConfigureR
 		clientSecret = config.ClientSecret.ValueString()
 	}
 
+	if !config.ClientSecretFilePath.IsNull() {
+		clientSecretFilePath = config.ClientSecretFilePath.ValueString()
+	}
+
+	if !config.ClientCertificate.IsNull() {
+		clientCertificate = config.ClientCertificate.ValueString()
+	}
+
+	if !config.ClientCertificatePath.IsNull() {
+		clientCertificatePath = config.ClientCertificatePath.ValueString()
+	}
+
+	if !config.ClientCertificatePassword.IsNull() {
+		clientCertificatePassword = config.ClientCertificatePassword.ValueString()
+	}
+
+	if !config.ClientCertificatePasswordFilePath.IsNull() {
+		clientCertificatePasswordFilePath = config.ClientCertificatePasswordFilePath.ValueString()
+	}
+
+	if !config.UseCli.IsNull() {
+		useCli = config.UseCli.ValueBool()
+	}
+
+	if !config.UseMsi.IsNull() {
+		useMsi = config.UseMsi.ValueBool()
+	}
+
+	if !config.UseOidc.IsNull() {
+		useOidc = config.UseOidc.ValueBool()
+	}
+
+	if !config.OidcToken.IsNull() {
+		oidcToken = config.OidcToken.ValueString()
+	}
+
+	if !config.OidcTokenFilePath.IsNull() {
+		oidcTokenFilePath = config.OidcTokenFilePath.ValueString()
+	}
+
+	if !config.OidcRequestUrl.IsNull() {
+		oidcRequestUrl = config.OidcRequestUrl.ValueString()
+	}
+
+	if !config.OidcRequestToken.IsNull() {
+		oidcRequestToken = config.OidcRequestToken.ValueString()
+	}
+
+	if !config.SkipCredentialsValidation.IsNull() {
+		skipCredentialsValidation = config.SkipCredentialsValidation.ValueBool()
+	}
+
 	if !config.PoolManagementGroup.IsNull() {
 		poolManagementGroupId = config.PoolManagementGroup.ValueString()
 	}
@@ -170,89 +697,111 @@ func (p *azurecnProvider) Configure(ctx context.Context, req provider.ConfigureR
 		poolSubscriptionPrefix = config.PoolSubscriptionNamePrefix.ValueString()
 	}
 
+	var pools map[string]azurecnPoolModel
+	if !config.Pools.IsNull() {
+		resp.Diagnostics.Append(config.Pools.ElementsAs(ctx, &pools, false)...)
+	}
+
 	// If any of the expected configurations are missing, return
 	// errors with provider-specific guidance.
 
-	if tenantId == "" {
+	cloudConfiguration, ok := cloudEnvironments[strings.ToLower(environment)]
+	if !ok {
 		resp.Diagnostics.AddAttributeError(
-			path.Root("tenant_id"),
-			"Missing Azure API TenantId",
-			"The provider cannot create the Azure API client as there is a missing or empty value for the Azure API tenant_id. "+
-				"Set the tenant_id value in the configuration or use the ARM_TENANT_ID environment variable. "+
-				"If either is already set, ensure the value is not empty.",
+			path.Root("environment"),
+			"Invalid Azure environment",
+			fmt.Sprintf("The Azure environment '%s' is not supported. Use one of public, usgovernment or china.", environment),
 		)
 	}
 
-	if clientId == "" {
-		resp.Diagnostics.AddAttributeError(
-			path.Root("client_id"),
-			"Missing Azure API ClientId",
-			"The provider cannot create the Azure API client as there is a missing or empty value for the Azure API client_id. "+
-				"Set the client_id value in the configuration or use the ARM_CLIENT_ID environment variable. "+
-				"If either is already set, ensure the value is not empty.",
-		)
-	}
+	credentials := credentialConfig{
+		disableInstanceDiscovery: authorityHost != "",
 
-	if clientSecret == "" {
-		resp.Diagnostics.AddAttributeError(
-			path.Root("client_secret"),
-			"Missing Azure API ClientSecret",
-			"The provider cannot create the Azure API client as there is a missing or empty value for the Azure API client_secret. "+
-				"Set the client_secret value in the configuration or use the ARM_CLIENT_SECRET environment variable. "+
-				"If either is already set, ensure the value is not empty.",
-		)
+		tenantId:             tenantId,
+		clientId:             clientId,
+		clientSecret:         clientSecret,
+		clientSecretFilePath: clientSecretFilePath,
+		useCli:               useCli,
+		useMsi:               useMsi,
+
+		clientCertificate:                 clientCertificate,
+		clientCertificatePath:             clientCertificatePath,
+		clientCertificatePassword:         clientCertificatePassword,
+		clientCertificatePasswordFilePath: clientCertificatePasswordFilePath,
+
+		useOidc:           useOidc,
+		oidcToken:         oidcToken,
+		oidcTokenFilePath: oidcTokenFilePath,
+		oidcRequestUrl:    oidcRequestUrl,
+		oidcRequestToken:  oidcRequestToken,
 	}
 
+	resp.Diagnostics.Append(credentials.validate(path.Empty())...)
+
+	retryOptions, diags := config.Retry.retryOptions(ctx)
+	resp.Diagnostics.Append(diags...)
+	rateLimits, diags := config.RateLimit.rateLimits()
+	resp.Diagnostics.Append(diags...)
+
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	// Create a new HashiCups client using the configuration values
-	var credentials, err = azidentity.NewClientSecretCredential(tenantId, clientId, clientSecret, &azidentity.ClientSecretCredentialOptions{})
+	clientOptions, err := newClientOptions(clientOptionsConfig{
+		cloud:                   cloudConfiguration,
+		resourceManagerEndpoint: resourceManagerEndpoint,
+		authorityHost:           authorityHost,
+		insecureSkipTlsVerify:   insecureSkipTlsVerify,
+		caCertificatePath:       caCertificatePath,
+		retry:                   retryOptions,
+	})
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create Azure API Credentials", err.Error())
-	}
-	managementGroupFactory, err := armmanagementgroups.NewClientFactory(credentials, nil)
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Create Azure API Client factory",
-			"An unexpected error occurred when creating the Azure API client factory. "+
-				"If the error is not clear, please contact the provider developers.\n\n"+
-				"Azure Client Error: "+err.Error(),
-		)
-		return
-	}
-	subscrioptionFactory, err := armsubscription.NewClientFactory(credentials, nil)
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Create Azure API Client factory",
-			"An unexpected error occurred when creating the Azure API client factory. "+
-				"If the error is not clear, please contact the provider developers.\n\n"+
-				"Azure Client Error: "+err.Error(),
-		)
+		resp.Diagnostics.AddError("Failed to create Azure API client options", err.Error())
 		return
 	}
 
-	availableSubscription, err := findAvailableSubscriptions(managementGroupFactory, poolManagementGroupId, poolSubscriptionPrefix)
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Failed while fetching subsciption pool content",
-			err.Error(),
-		)
+	builder := &poolClientBuilder{
+		version:                   p.version,
+		clientOptions:             clientOptions,
+		skipCredentialsValidation: skipCredentialsValidation,
+		rateLimits:                rateLimits,
+		tenants:                   map[string]*tenantClients{},
+	}
+	client, diags := builder.newPoolClient(credentials, poolManagementGroupId, poolSubscriptionPrefix)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	var client = BaseClient{
-		managementGroupClientFactory: managementGroupFactory,
-		subscriptionClientFactory:    subscrioptionFactory,
-		availableSubscriptions:       availableSubscription,
-		poolManagementGroupId:        poolManagementGroupId,
-		poolSubscriptionPrefix:       poolSubscriptionPrefix,
+	client.pools = make(map[string]*BaseClient, len(pools))
+	for name, pool := range pools {
+		poolSubscriptionPrefix := client.poolSubscriptionPrefix
+		if !pool.NamePrefix.IsNull() {
+			poolSubscriptionPrefix = pool.NamePrefix.ValueString()
+		}
+
+		poolCredentials := pool.credentials(credentials)
+		resp.Diagnostics.Append(poolCredentials.validate(path.Root("pools").AtMapKey(name))...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		poolClient, diags := builder.newPoolClient(poolCredentials, pool.ManagementGroup.ValueString(), poolSubscriptionPrefix)
+		for _, d := range diags {
+			resp.Diagnostics.AddAttributeError(path.Root("pools").AtMapKey(name), d.Summary(), d.Detail())
+		}
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		client.pools[name] = poolClient
 	}
+
 	// Make the HashiCups client available during DataSource and Resource
 	// type Configure methods.
-	resp.DataSourceData = &client
-	resp.ResourceData = &client
+	resp.DataSourceData = client
+	resp.ResourceData = client
+	resp.EphemeralResourceData = client
+	resp.ListResourceData = client
 }
 
 // DataSources defines the data sources implemented in the provider.
@@ -267,19 +816,231 @@ func (p *azurecnProvider) Resources(_ context.Context) []func() resource.Resourc
 	}
 }
 
-func findAvailableSubscriptions(clientFactory *armmanagementgroups.ClientFactory, managementGroupId string, subscriptionPrefix string) (chan string, error) {
-	subscriptionPager := clientFactory.NewManagementGroupSubscriptionsClient().NewGetSubscriptionsUnderManagementGroupPager(managementGroupId, nil)
-	var matchingSubscriptions []string
+// EphemeralResources defines the ephemeral resources implemented in the provider.
+func (p *azurecnProvider) EphemeralResources(_ context.Context) []func() ephemeral.EphemeralResource {
+	return []func() ephemeral.EphemeralResource{
+		NewSubscriptionPoolLeaseEphemeralResource,
+	}
+}
+
+// ListResources defines the list resources implemented in the provider.
+func (p *azurecnProvider) ListResources(_ context.Context) []func() list.ListResource {
+	return []func() list.ListResource{
+		NewSubscriptionPoolLeaseListResource,
+	}
+}
+
+// setsAuthentication reports whether the pool configures an authentication method of its own:
+// a client secret, a client certificate or one of the use_* switches.
+func (pool azurecnPoolModel) setsAuthentication() bool {
+	return !pool.ClientSecret.IsNull() || !pool.ClientSecretFilePath.IsNull() ||
+		!pool.ClientCertificate.IsNull() || !pool.ClientCertificatePath.IsNull() ||
+		pool.UseCli.ValueBool() || pool.UseMsi.ValueBool() || pool.UseOidc.ValueBool()
+}
+
+// credentials returns the credential settings of the pool, falling back to the given provider level settings.
+// A pool with an authentication method of its own doesn't inherit the provider's, so e.g. the provider's
+// use_cli can't take precedence over the pool's client secret.
+func (pool azurecnPoolModel) credentials(inherited credentialConfig) credentialConfig {
+	credentials := inherited
+	if pool.setsAuthentication() {
+		credentials.clientSecret = ""
+		credentials.clientSecretFilePath = ""
+		credentials.clientCertificate = ""
+		credentials.clientCertificatePath = ""
+		credentials.clientCertificatePassword = ""
+		credentials.clientCertificatePasswordFilePath = ""
+		credentials.useCli = false
+		credentials.useMsi = false
+		credentials.useOidc = false
+	}
+	if !pool.ClientCertificatePassword.IsNull() || !pool.ClientCertificatePasswordFilePath.IsNull() {
+		credentials.clientCertificatePassword = ""
+		credentials.clientCertificatePasswordFilePath = ""
+	}
+	if !pool.TenantId.IsNull() {
+		credentials.tenantId = pool.TenantId.ValueString()
+	}
+	if !pool.ClientId.IsNull() {
+		credentials.clientId = pool.ClientId.ValueString()
+	}
+	if !pool.ClientSecret.IsNull() {
+		credentials.clientSecret = pool.ClientSecret.ValueString()
+	}
+	if !pool.ClientSecretFilePath.IsNull() {
+		credentials.clientSecretFilePath = pool.ClientSecretFilePath.ValueString()
+	}
+	if !pool.ClientCertificate.IsNull() {
+		credentials.clientCertificate = pool.ClientCertificate.ValueString()
+	}
+	if !pool.ClientCertificatePath.IsNull() {
+		credentials.clientCertificatePath = pool.ClientCertificatePath.ValueString()
+	}
+	if !pool.ClientCertificatePassword.IsNull() {
+		credentials.clientCertificatePassword = pool.ClientCertificatePassword.ValueString()
+	}
+	if !pool.ClientCertificatePasswordFilePath.IsNull() {
+		credentials.clientCertificatePasswordFilePath = pool.ClientCertificatePasswordFilePath.ValueString()
+	}
+	if !pool.UseCli.IsNull() {
+		credentials.useCli = pool.UseCli.ValueBool()
+	}
+	if !pool.UseMsi.IsNull() {
+		credentials.useMsi = pool.UseMsi.ValueBool()
+	}
+	if !pool.UseOidc.IsNull() {
+		credentials.useOidc = pool.UseOidc.ValueBool()
+	}
+	if !pool.OidcToken.IsNull() {
+		credentials.oidcToken = pool.OidcToken.ValueString()
+	}
+	if !pool.OidcTokenFilePath.IsNull() {
+		credentials.oidcTokenFilePath = pool.OidcTokenFilePath.ValueString()
+	}
+	return credentials
+}
+
+// tenantClientFactories is the client factory pair shared by all pools of one tenant.
+type tenantClientFactories struct {
+	armClient                    *arm.Client
+	managementGroupClientFactory *armmanagementgroups.ClientFactory
+	subscriptionClientFactory    *armsubscription.ClientFactory
+	hierarchy                    *hierarchyCache
+	rateLimiter                  *rateLimiter
+}
+
+// tenantClients creates the client factories of one tenant once, when the first of its pools is used.
+type tenantClients struct {
+	credentials credentialConfig
+
+	mu        sync.Mutex
+	factories *tenantClientFactories
+	diags     diag.Diagnostics
+}
+
+// poolClientBuilder creates the clients of all configured pools. The client factories
+// of a tenant are shared with every other pool of that tenant.
+type poolClientBuilder struct {
+	version                   string
+	clientOptions             azcore.ClientOptions
+	skipCredentialsValidation bool
+	rateLimits                map[operationClass]rateLimit
+	tenants                   map[string]*tenantClients
+}
+
+// newPoolClient creates the client of one subscription pool. Nothing is sent to Azure until the pool is used.
+func (b *poolClientBuilder) newPoolClient(credentials credentialConfig, poolManagementGroupId string, poolSubscriptionPrefix string) (*BaseClient, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	tenant, ok := b.tenants[credentials.tenantId]
+	if ok && tenant.credentials != credentials {
+		diags.AddError(
+			"Conflicting Azure API credentials",
+			fmt.Sprintf("The tenant '%s' is configured with different credentials. Pools in the same tenant must share their credentials.", credentials.tenantId),
+		)
+		return nil, diags
+	}
+
+	if !ok {
+		tenant = &tenantClients{credentials: credentials}
+		b.tenants[credentials.tenantId] = tenant
+	}
+
+	return &BaseClient{
+		poolManagementGroupId:  poolManagementGroupId,
+		poolSubscriptionPrefix: poolSubscriptionPrefix,
+		skipPoolValidation:     b.skipCredentialsValidation,
+		connect: func(ctx context.Context) (*tenantClientFactories, diag.Diagnostics) {
+			return b.connectTenant(ctx, tenant)
+		},
+	}, diags
+}
+
+// connectTenant creates the client factories of the tenant, if that didn't happen yet.
+// Failures caused by the caller's context, e.g. a timeout, are not kept, so the next caller tries again.
+func (b *poolClientBuilder) connectTenant(ctx context.Context, tenant *tenantClients) (*tenantClientFactories, diag.Diagnostics) {
+	tenant.mu.Lock()
+	defer tenant.mu.Unlock()
+	if tenant.factories != nil || tenant.diags.HasError() {
+		return tenant.factories, tenant.diags
+	}
+
+	factories, diags := b.newTenantClientFactories(ctx, tenant.credentials)
+	if diags.HasError() && ctx.Err() != nil {
+		return nil, diags
+	}
+	tenant.factories, tenant.diags = factories, diags
+	return factories, diags
+}
+
+func (b *poolClientBuilder) newTenantClientFactories(ctx context.Context, credentials credentialConfig) (*tenantClientFactories, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	tokenCredential, err := newCredential(credentials, b.clientOptions)
+	if err != nil {
+		diags.AddError("Failed to create Azure API Credentials", err.Error())
+		return nil, diags
+	}
 
-	for subscriptionPager.More() {
-		page, err := subscriptionPager.NextPage(context.Background())
-		if err != nil {
-			return nil, err
+	if !b.skipCredentialsValidation {
+		if err := checkToken(ctx, tokenCredential, b.clientOptions); err != nil {
+			diags.AddError(
+				"Failed to authenticate against the Azure API",
+				"The provider could not acquire a token with the configured credentials. Check the tenant, client and secret settings.\n\n"+
+					"Azure Identity Error: "+err.Error(),
+			)
+			return nil, diags
 		}
-		for _, sub := range page.Value {
-			if strings.HasPrefix(*sub.Properties.DisplayName, subscriptionPrefix) {
-				matchingSubscriptions = append(matchingSubscriptions, *sub.Name)
-			}
+	}
+
+	armOptions := &arm.ClientOptions{ClientOptions: b.clientOptions}
+	armClient, err := arm.NewClient("terraform-provider-azurecnp", b.version, tokenCredential, armOptions)
+	if err != nil {
+		diags.AddError(
+			"Unable to Create Azure API Client",
+			"An unexpected error occurred when creating the Azure API client. "+
+				"If the error is not clear, please contact the provider developers.\n\n"+
+				"Azure Client Error: "+err.Error(),
+		)
+		return nil, diags
+	}
+	managementGroupFactory, err := armmanagementgroups.NewClientFactory(tokenCredential, armOptions)
+	if err != nil {
+		diags.AddError(
+			"Unable to Create Azure API Client factory",
+			"An unexpected error occurred when creating the Azure API client factory. "+
+				"If the error is not clear, please contact the provider developers.\n\n"+
+				"Azure Client Error: "+err.Error(),
+		)
+		return nil, diags
+	}
+	subscrioptionFactory, err := armsubscription.NewClientFactory(tokenCredential, armOptions)
+	if err != nil {
+		diags.AddError(
+			"Unable to Create Azure API Client factory",
+			"An unexpected error occurred when creating the Azure API client factory. "+
+				"If the error is not clear, please contact the provider developers.\n\n"+
+				"Azure Client Error: "+err.Error(),
+		)
+		return nil, diags
+	}
+
+	limiter := newRateLimiter(b.rateLimits)
+	return &tenantClientFactories{
+		armClient:                    armClient,
+		managementGroupClientFactory: managementGroupFactory,
+		subscriptionClientFactory:    subscrioptionFactory,
+		hierarchy:                    newHierarchyCache(managementGroupFactory, limiter),
+		rateLimiter:                  limiter,
+	}, diags
+}
+
+// findAvailableSubscriptions queues the subscriptions in the pool management group that carry the pool prefix.
+func findAvailableSubscriptions(subscriptions []subscriptionState, managementGroupId string, subscriptionPrefix string) chan string {
+	var matchingSubscriptions []string
+	for _, subscription := range subscriptions {
+		if isPoolSubscription(subscription.parentManagementGroup, subscription.displayName, managementGroupId, subscriptionPrefix) {
+			matchingSubscriptions = append(matchingSubscriptions, subscription.subscriptionId)
 		}
 	}
 
@@ -289,5 +1050,35 @@ func findAvailableSubscriptions(clientFactory *armmanagementgroups.ClientFactory
 	}
 
 	close(resultChannel)
-	return resultChannel, nil
+	return resultChannel
+}
+
+// getEnvWithFallback returns the first non-empty environment variable of the given keys.
+func getEnvWithFallback(keys ...string) string {
+	for _, key := range keys {
+		if value := os.Getenv(key); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func countNonEmpty(values ...string) int {
+	count := 0
+	for _, value := range values {
+		if value != "" {
+			count++
+		}
+	}
+	return count
+}
+
+func countTrue(values ...bool) int {
+	count := 0
+	for _, value := range values {
+		if value {
+			count++
+		}
+	}
+	return count
 }
@@ -0,0 +1,137 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"golang.org/x/time/rate"
+)
+
+// operationClass groups the requests that Azure throttles together.
+type operationClass string
+
+const (
+	operationRead   operationClass = "read"
+	operationMove   operationClass = "move"
+	operationRename operationClass = "rename"
+)
+
+// rateLimit is the token bucket of one operation class.
+type rateLimit struct {
+	requestsPerSecond rate.Limit
+	burst             int
+}
+
+// defaultRateLimits keep many parallel leases below the tenant wide throttling of moves and renames.
+// Reads are not limited unless configured.
+var defaultRateLimits = map[operationClass]rateLimit{
+	operationRead:   {requestsPerSecond: rate.Inf},
+	operationMove:   {requestsPerSecond: 2, burst: 5},
+	operationRename: {requestsPerSecond: 1, burst: 2},
+}
+
+// azurecnRateLimitModel is the rate_limit block of the provider configuration.
+type azurecnRateLimitModel struct {
+	Read   *azurecnOperationRateLimitModel `tfsdk:"read"`
+	Move   *azurecnOperationRateLimitModel `tfsdk:"move"`
+	Rename *azurecnOperationRateLimitModel `tfsdk:"rename"`
+}
+
+// azurecnOperationRateLimitModel is the rate limit of one operation class.
+type azurecnOperationRateLimitModel struct {
+	RequestsPerSecond types.Float64 `tfsdk:"requests_per_second"`
+	Burst             types.Int64   `tfsdk:"burst"`
+}
+
+func (m *azurecnRateLimitModel) operations() map[operationClass]*azurecnOperationRateLimitModel {
+	return map[operationClass]*azurecnOperationRateLimitModel{
+		operationRead:   m.Read,
+		operationMove:   m.Move,
+		operationRename: m.Rename,
+	}
+}
+
+// isUnknown reports whether any setting of the rate_limit block is unknown.
+func (m *azurecnRateLimitModel) isUnknown() bool {
+	for _, operation := range m.operations() {
+		if operation != nil && (operation.RequestsPerSecond.IsUnknown() || operation.Burst.IsUnknown()) {
+			return true
+		}
+	}
+	return false
+}
+
+// rateLimits converts the rate_limit block into the rate limit of every operation class.
+// Operation classes that are not configured keep their default.
+func (m *azurecnRateLimitModel) rateLimits() (map[operationClass]rateLimit, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	limits := map[operationClass]rateLimit{}
+	for class, limit := range defaultRateLimits {
+		limits[class] = limit
+	}
+	if m == nil {
+		return limits, diags
+	}
+
+	for class, operation := range m.operations() {
+		if operation == nil || operation.RequestsPerSecond.IsNull() {
+			continue
+		}
+		attributePath := path.Root("rate_limit").AtName(string(class))
+
+		requestsPerSecond := operation.RequestsPerSecond.ValueFloat64()
+		if requestsPerSecond <= 0 {
+			diags.AddAttributeError(
+				attributePath.AtName("requests_per_second"),
+				"Invalid requests_per_second",
+				fmt.Sprintf("requests_per_second must be positive, got %g.", requestsPerSecond),
+			)
+			continue
+		}
+
+		burst := int64(1)
+		if !operation.Burst.IsNull() {
+			burst = operation.Burst.ValueInt64()
+		}
+		if burst < 1 {
+			diags.AddAttributeError(
+				attributePath.AtName("burst"),
+				"Invalid burst",
+				fmt.Sprintf("burst must be at least 1, got %d.", burst),
+			)
+			continue
+		}
+
+		limits[class] = rateLimit{requestsPerSecond: rate.Limit(requestsPerSecond), burst: int(min(burst, 1000))}
+	}
+	return limits, diags
+}
+
+// rateLimiter smooths the requests of a tenant with a token bucket per operation class.
+// It is shared by all pools of the tenant, since Azure throttles per tenant.
+type rateLimiter struct {
+	limiters map[operationClass]*rate.Limiter
+}
+
+func newRateLimiter(limits map[operationClass]rateLimit) *rateLimiter {
+	limiter := &rateLimiter{limiters: map[operationClass]*rate.Limiter{}}
+	for class, limit := range limits {
+		limiter.limiters[class] = rate.NewLimiter(limit.requestsPerSecond, limit.burst)
+	}
+	return limiter
+}
+
+// wait blocks until a request of the operation class may be sent, or the context is done.
+func (l *rateLimiter) wait(ctx context.Context, class operationClass) error {
+	limiter, ok := l.limiters[class]
+	if !ok {
+		return nil
+	}
+	if err := limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("waiting for the %s rate limit: %w", class, err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// azurecnRetryModel is the retry block of the provider configuration.
+type azurecnRetryModel struct {
+	MaxRetries    types.Int64  `tfsdk:"max_retries"`
+	RetryDelay    types.String `tfsdk:"retry_delay"`
+	MaxRetryDelay types.String `tfsdk:"max_retry_delay"`
+	StatusCodes   types.List   `tfsdk:"status_codes"`
+}
+
+// isUnknown reports whether any setting of the retry block is unknown.
+func (m *azurecnRetryModel) isUnknown() bool {
+	return m.MaxRetries.IsUnknown() || m.RetryDelay.IsUnknown() || m.MaxRetryDelay.IsUnknown() || m.StatusCodes.IsUnknown()
+}
+
+// retryOptions converts the retry block into the retry options of the Azure SDK. Settings that are not
+// set keep the SDK defaults. A Retry-After header sent by ARM takes precedence over the delays,
+// unless it exceeds the maximum delay, in which case the SDK gives up right away.
+func (m *azurecnRetryModel) retryOptions(ctx context.Context) (policy.RetryOptions, diag.Diagnostics) {
+	var options policy.RetryOptions
+	var diags diag.Diagnostics
+	if m == nil {
+		return options, diags
+	}
+
+	if !m.MaxRetries.IsNull() {
+		switch maxRetries := m.MaxRetries.ValueInt64(); {
+		case maxRetries < 0:
+			diags.AddAttributeError(path.Root("retry").AtName("max_retries"), "Invalid max_retries", "max_retries must not be negative.")
+		case maxRetries == 0:
+			// The SDK treats 0 as "use the default", -1 disables retries.
+			options.MaxRetries = -1
+		default:
+			options.MaxRetries = int32(min(maxRetries, 100))
+		}
+	}
+
+	options.RetryDelay = parseRetryDelay(m.RetryDelay, path.Root("retry").AtName("retry_delay"), &diags)
+	options.MaxRetryDelay = parseRetryDelay(m.MaxRetryDelay, path.Root("retry").AtName("max_retry_delay"), &diags)
+
+	if !m.StatusCodes.IsNull() {
+		var statusCodes []int64
+		diags.Append(m.StatusCodes.ElementsAs(ctx, &statusCodes, false)...)
+		// An empty, non-nil list tells the SDK not to retry on any status code.
+		options.StatusCodes = []int{}
+		for _, statusCode := range statusCodes {
+			options.StatusCodes = append(options.StatusCodes, int(statusCode))
+		}
+	}
+
+	return options, diags
+}
+
+func parseRetryDelay(value types.String, attributePath path.Path, diags *diag.Diagnostics) time.Duration {
+	if value.IsNull() {
+		return 0
+	}
+	delay, err := time.ParseDuration(value.ValueString())
+	if err != nil || delay <= 0 {
+		diags.AddAttributeError(
+			attributePath,
+			"Invalid retry delay",
+			fmt.Sprintf("'%s' is not a positive duration like \"4s\" or \"1m\".", value.ValueString()),
+		)
+		return 0
+	}
+	return delay
+}
+
+// attemptCounterKey is the context key of the attempt counter installed by withAttemptCounter.
+type attemptCounterKey struct{}
+
+// withAttemptCounter returns a context in which attemptCountingPolicy counts every try of a request,
+// so callers can tell how often the SDK retried before it gave up.
+func withAttemptCounter(ctx context.Context) (context.Context, *atomic.Int32) {
+	counter := &atomic.Int32{}
+	return context.WithValue(ctx, attemptCounterKey{}, counter), counter
+}
+
+// attemptCountingPolicy runs once per try of a request, see withAttemptCounter.
+type attemptCountingPolicy struct{}
+
+func (attemptCountingPolicy) Do(req *policy.Request) (*http.Response, error) {
+	if counter, ok := req.Raw().Context().Value(attemptCounterKey{}).(*atomic.Int32); ok {
+		counter.Add(1)
+	}
+	return req.Next()
+}
+
+// withRetryCount adds the number of retries to the error of a request that was retried.
+func withRetryCount(err error, attempts *atomic.Int32) error {
+	if err == nil || attempts.Load() <= 1 {
+		return err
+	}
+	return fmt.Errorf("giving up after %d retries: %w", attempts.Load()-1, err)
+}
@@ -0,0 +1,146 @@
+package provider
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// newTestRetryModel returns a retry block with all settings null.
+func newTestRetryModel() *azurecnRetryModel {
+	return &azurecnRetryModel{
+		MaxRetries:    types.Int64Null(),
+		RetryDelay:    types.StringNull(),
+		MaxRetryDelay: types.StringNull(),
+		StatusCodes:   types.ListNull(types.Int64Type),
+	}
+}
+
+func TestRetryOptions(t *testing.T) {
+	testCases := map[string]struct {
+		model func() *azurecnRetryModel
+
+		expectedMaxRetries    int32
+		expectedRetryDelay    time.Duration
+		expectedMaxRetryDelay time.Duration
+		expectedStatusCodes   []int
+		expectError           bool
+	}{
+		"no retry block": {
+			model: func() *azurecnRetryModel { return nil },
+		},
+		"empty retry block": {
+			model: newTestRetryModel,
+		},
+		"no retries": {
+			model: func() *azurecnRetryModel {
+				model := newTestRetryModel()
+				model.MaxRetries = types.Int64Value(0)
+				return model
+			},
+			expectedMaxRetries: -1,
+		},
+		"some retries": {
+			model: func() *azurecnRetryModel {
+				model := newTestRetryModel()
+				model.MaxRetries = types.Int64Value(5)
+				return model
+			},
+			expectedMaxRetries: 5,
+		},
+		"too many retries": {
+			model: func() *azurecnRetryModel {
+				model := newTestRetryModel()
+				model.MaxRetries = types.Int64Value(500)
+				return model
+			},
+			expectedMaxRetries: 100,
+		},
+		"negative retries": {
+			model: func() *azurecnRetryModel {
+				model := newTestRetryModel()
+				model.MaxRetries = types.Int64Value(-1)
+				return model
+			},
+			expectError: true,
+		},
+		"delays": {
+			model: func() *azurecnRetryModel {
+				model := newTestRetryModel()
+				model.RetryDelay = types.StringValue("4s")
+				model.MaxRetryDelay = types.StringValue("1m")
+				return model
+			},
+			expectedRetryDelay:    4 * time.Second,
+			expectedMaxRetryDelay: time.Minute,
+		},
+		"invalid delay": {
+			model: func() *azurecnRetryModel {
+				model := newTestRetryModel()
+				model.RetryDelay = types.StringValue("soon")
+				return model
+			},
+			expectError: true,
+		},
+		"zero delay": {
+			model: func() *azurecnRetryModel {
+				model := newTestRetryModel()
+				model.MaxRetryDelay = types.StringValue("0s")
+				return model
+			},
+			expectError: true,
+		},
+		"status codes": {
+			model: func() *azurecnRetryModel {
+				model := newTestRetryModel()
+				model.StatusCodes = types.ListValueMust(types.Int64Type, []attr.Value{types.Int64Value(429), types.Int64Value(503)})
+				return model
+			},
+			expectedStatusCodes: []int{429, 503},
+		},
+		"no status codes": {
+			model: func() *azurecnRetryModel {
+				model := newTestRetryModel()
+				model.StatusCodes = types.ListValueMust(types.Int64Type, []attr.Value{})
+				return model
+			},
+			expectedStatusCodes: []int{},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			options, diags := testCase.model().retryOptions(context.Background())
+			if testCase.expectError {
+				if !diags.HasError() {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			if options.MaxRetries != testCase.expectedMaxRetries {
+				t.Errorf("MaxRetries = %d, want %d", options.MaxRetries, testCase.expectedMaxRetries)
+			}
+			if options.RetryDelay != testCase.expectedRetryDelay {
+				t.Errorf("RetryDelay = %s, want %s", options.RetryDelay, testCase.expectedRetryDelay)
+			}
+			if options.MaxRetryDelay != testCase.expectedMaxRetryDelay {
+				t.Errorf("MaxRetryDelay = %s, want %s", options.MaxRetryDelay, testCase.expectedMaxRetryDelay)
+			}
+			// The SDK retries its default status codes for a nil list, and none for an empty one.
+			if (options.StatusCodes == nil) != (testCase.expectedStatusCodes == nil) {
+				t.Errorf("StatusCodes = %#v, want %#v", options.StatusCodes, testCase.expectedStatusCodes)
+			}
+			if !slices.Equal(options.StatusCodes, testCase.expectedStatusCodes) {
+				t.Errorf("StatusCodes = %v, want %v", options.StatusCodes, testCase.expectedStatusCodes)
+			}
+		})
+	}
+}
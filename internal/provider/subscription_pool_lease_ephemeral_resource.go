@@ -0,0 +1,250 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ ephemeral.EphemeralResource              = &subscriptionPoolLeaseEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithConfigure = &subscriptionPoolLeaseEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithRenew     = &subscriptionPoolLeaseEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithClose     = &subscriptionPoolLeaseEphemeralResource{}
+)
+
+// subscriptionPoolLeaseRenewInterval is how often Terraform asks us to check that the lease is still held.
+const subscriptionPoolLeaseRenewInterval = 5 * time.Minute
+
+// subscriptionPoolLeaseRollbackTimeout bounds returning the subscription to the pool when Open fails halfway.
+const subscriptionPoolLeaseRollbackTimeout = 5 * time.Minute
+
+// NewSubscriptionPoolLeaseEphemeralResource is a helper function to simplify the provider implementation.
+func NewSubscriptionPoolLeaseEphemeralResource() ephemeral.EphemeralResource {
+	return &subscriptionPoolLeaseEphemeralResource{}
+}
+
+// subscriptionPoolLeaseEphemeralResource is the ephemeral resource implementation.
+// It leases a subscription for the duration of a single Terraform run and returns it to the pool afterwards.
+type subscriptionPoolLeaseEphemeralResource struct {
+	baseClient *BaseClient
+}
+
+type subscriptionPoolLeaseEphemeralResourceModel struct {
+	TargetManagementGroupName    types.String `tfsdk:"target_management_group_name"`
+	TargetSubscriptionName       types.String `tfsdk:"target_subscription_name"`
+	SubscriptionId               types.String `tfsdk:"subscription_id"`
+	QualifiedSubscriptionId      types.String `tfsdk:"qualified_subscription_id"`
+	FullyQualifiedSubscriptionId types.String `tfsdk:"fully_qualified_subscription_id"`
+	Pool                         types.String `tfsdk:"pool"`
+}
+
+// subscriptionPoolLeasePrivateData is kept in the private data between Open, Renew and Close.
+type subscriptionPoolLeasePrivateData struct {
+	SubscriptionId            string `json:"subscription_id"`
+	TargetManagementGroupName string `json:"target_management_group_name"`
+	Pool                      string `json:"pool"`
+}
+
+const subscriptionPoolLeasePrivateKey = "lease"
+
+// Metadata returns the ephemeral resource type name.
+func (r *subscriptionPoolLeaseEphemeralResource) Metadata(_ context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_subscription_pool_lease"
+}
+
+// Schema defines the schema for the ephemeral resource.
+func (r *subscriptionPoolLeaseEphemeralResource) Schema(_ context.Context, _ ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Leases a subscription from the pool for the duration of a single Terraform run and returns it to the pool when the run ends.",
+		Attributes: map[string]schema.Attribute{
+			"target_management_group_name": schema.StringAttribute{
+				Description: "the ID; either a GUID or a named ID",
+				Required:    true,
+			},
+			"target_subscription_name": schema.StringAttribute{
+				Description: "the desired name of the subscription",
+				Required:    true,
+			},
+			"subscription_id": schema.StringAttribute{
+				Description: "like: 00000000-0000-0000-0000-000000000000",
+				Computed:    true,
+			},
+			"qualified_subscription_id": schema.StringAttribute{
+				Description: "like: /subscriptions/00000000-0000-0000-0000-000000000000",
+				Computed:    true,
+			},
+			"fully_qualified_subscription_id": schema.StringAttribute{
+				Description: "like: /providers/Microsoft.Management/managementGroups/00000000-0000-0000-0000-000000000000/subscriptions/00000000-0000-0000-0000-000000000000",
+				Computed:    true,
+			},
+			"pool": schema.StringAttribute{
+				Description: "the name of a pool from the provider's pools; the provider's default pool if unset",
+				Optional:    true,
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the ephemeral resource.
+func (r *subscriptionPoolLeaseEphemeralResource) Configure(_ context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	// Add a nil check when handling ProviderData because Terraform
+	// sets that data after it calls the ConfigureProvider RPC.
+	if req.ProviderData == nil {
+		return
+	}
+
+	baseClient, ok := req.ProviderData.(*BaseClient)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Ephemeral Resource Configure Type",
+			fmt.Sprintf("Expected *provider.BaseClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.baseClient = baseClient
+}
+
+// Open takes a subscription from the pool and moves it to the target management group.
+func (r *subscriptionPoolLeaseEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	var data subscriptionPoolLeaseEphemeralResourceModel
+	diags := req.Config.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	pool, diags := r.baseClient.Pool(ctx, data.Pool.ValueString())
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	subscriptionId, err := pool.NextAvailableSubscription(ctx)
+	if err != nil {
+		resp.Diagnostics.Append(errorDiagnostic("Failed while fetching subsciption pool content", err))
+		return
+	}
+
+	privateData, err := json.Marshal(subscriptionPoolLeasePrivateData{
+		SubscriptionId:            subscriptionId,
+		TargetManagementGroupName: data.TargetManagementGroupName.ValueString(),
+		Pool:                      data.Pool.ValueString(),
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Error encoding lease", err.Error())
+		return
+	}
+
+	associationResponse, err := pool.MoveSubscription(ctx, subscriptionId, pool.poolManagementGroupId, data.TargetManagementGroupName.ValueString())
+	if err != nil {
+		resp.Diagnostics.Append(errorDiagnostic("Error moving subscription", err))
+		return
+	}
+
+	_, err = pool.RenameSubscription(ctx, subscriptionId, data.TargetSubscriptionName.ValueString())
+	if err != nil {
+		resp.Diagnostics.Append(errorDiagnostic("Error renaming subscription", err))
+		// Terraform doesn't call Close when Open fails, so hand the subscription back to the pool here.
+		// The rename failed, so it still carries its pool name. The run's context may be what failed the rename.
+		rollbackCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), subscriptionPoolLeaseRollbackTimeout)
+		defer cancel()
+		if _, err := pool.MoveSubscription(rollbackCtx, subscriptionId, data.TargetManagementGroupName.ValueString(), pool.poolManagementGroupId); err != nil {
+			resp.Diagnostics.Append(errorDiagnostic(
+				fmt.Sprintf("Error returning subscription '%s' to the pool", subscriptionId),
+				fmt.Errorf("the subscription is left in ManagementGroup '%s'; move it back to '%s' manually: %w", data.TargetManagementGroupName.ValueString(), pool.poolManagementGroupId, err),
+			))
+		}
+		return
+	}
+
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, subscriptionPoolLeasePrivateKey, privateData)...)
+
+	data.SubscriptionId = types.StringValue(subscriptionId)
+	data.QualifiedSubscriptionId = types.StringValue(strings.TrimPrefix(*associationResponse.ID, *associationResponse.Properties.Parent.ID))
+	data.FullyQualifiedSubscriptionId = types.StringValue(*associationResponse.ID)
+
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+	resp.RenewAt = time.Now().Add(subscriptionPoolLeaseRenewInterval)
+}
+
+// Renew verifies that the leased subscription is still placed under the target management group.
+func (r *subscriptionPoolLeaseEphemeralResource) Renew(ctx context.Context, req ephemeral.RenewRequest, resp *ephemeral.RenewResponse) {
+	lease, diags := getSubscriptionPoolLeasePrivateData(ctx, req.Private)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || lease == nil {
+		return
+	}
+
+	pool, diags := r.baseClient.Pool(ctx, lease.Pool)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	_, err := pool.GetSubscription(ctx, lease.TargetManagementGroupName, lease.SubscriptionId)
+	if err != nil {
+		err = fmt.Errorf("could not find Subscription '%s' under ManagementGroup '%s': %w", lease.SubscriptionId, lease.TargetManagementGroupName, pool.classifyError(err))
+		resp.Diagnostics.Append(errorDiagnostic("Lost subscription lease", err))
+		return
+	}
+
+	resp.RenewAt = time.Now().Add(subscriptionPoolLeaseRenewInterval)
+}
+
+// Close moves the leased subscription back into the pool.
+func (r *subscriptionPoolLeaseEphemeralResource) Close(ctx context.Context, req ephemeral.CloseRequest, resp *ephemeral.CloseResponse) {
+	lease, diags := getSubscriptionPoolLeasePrivateData(ctx, req.Private)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || lease == nil {
+		return
+	}
+
+	pool, diags := r.baseClient.Pool(ctx, lease.Pool)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	_, err := pool.MoveSubscription(ctx, lease.SubscriptionId, lease.TargetManagementGroupName, pool.poolManagementGroupId)
+	if err != nil {
+		resp.Diagnostics.Append(errorDiagnostic("Error during Subscription Move", err))
+		return
+	}
+
+	newSubscriptionName := truncateString(pool.poolSubscriptionPrefix+lease.SubscriptionId, 64)
+	_, err = pool.RenameSubscription(ctx, lease.SubscriptionId, newSubscriptionName)
+	if err != nil {
+		resp.Diagnostics.Append(errorDiagnostic("Error during Subscription Rename", err))
+		return
+	}
+}
+
+type privateDataGetter interface {
+	GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+}
+
+func getSubscriptionPoolLeasePrivateData(ctx context.Context, private privateDataGetter) (*subscriptionPoolLeasePrivateData, diag.Diagnostics) {
+	raw, diags := private.GetKey(ctx, subscriptionPoolLeasePrivateKey)
+	if diags.HasError() || raw == nil {
+		return nil, diags
+	}
+
+	var lease subscriptionPoolLeasePrivateData
+	if err := json.Unmarshal(raw, &lease); err != nil {
+		diags.AddError("Error decoding lease", err.Error())
+		return nil, diags
+	}
+	return &lease, diags
+}
@@ -0,0 +1,191 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	"github.com/hashicorp/terraform-plugin-framework/list/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ list.ListResource                   = &subscriptionPoolLeaseListResource{}
+	_ list.ListResourceWithConfigure      = &subscriptionPoolLeaseListResource{}
+	_ list.ListResourceWithValidateConfig = &subscriptionPoolLeaseListResource{}
+)
+
+// NewSubscriptionPoolLeaseListResource is a helper function to simplify the provider implementation.
+func NewSubscriptionPoolLeaseListResource() list.ListResource {
+	return &subscriptionPoolLeaseListResource{}
+}
+
+// subscriptionPoolLeaseListResource discovers leased subscriptions, so they can be imported with `terraform query`.
+type subscriptionPoolLeaseListResource struct {
+	baseClient *BaseClient
+}
+
+type subscriptionPoolLeaseListResourceModel struct {
+	ManagementGroupName    types.String `tfsdk:"management_group_name"`
+	SubscriptionNamePrefix types.String `tfsdk:"subscription_name_prefix"`
+	Pool                   types.String `tfsdk:"pool"`
+}
+
+// Metadata returns the list resource type name.
+func (r *subscriptionPoolLeaseListResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_subscription_pool_lease"
+}
+
+// ListResourceConfigSchema defines the schema for the list block.
+func (r *subscriptionPoolLeaseListResource) ListResourceConfigSchema(_ context.Context, _ list.ListResourceSchemaRequest, resp *list.ListResourceSchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Lists subscriptions that were leased from the pool, i.e. subscriptions outside of the pool management group that do not carry the pool name prefix. " +
+			"Leases carry no marker of their own, so at least one of management_group_name and subscription_name_prefix is required to tell them apart from the other subscriptions of the tenant.",
+		Attributes: map[string]schema.Attribute{
+			"management_group_name": schema.StringAttribute{
+				Description: "only list subscriptions placed directly under this management group; required unless subscription_name_prefix is set",
+				Optional:    true,
+			},
+			"subscription_name_prefix": schema.StringAttribute{
+				Description: "only list subscriptions whose display name starts with this prefix; required unless management_group_name is set",
+				Optional:    true,
+			},
+			"pool": schema.StringAttribute{
+				Description: "the name of a pool from the provider's pools to list the leases of; the provider's default pool if unset",
+				Optional:    true,
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the list resource.
+func (r *subscriptionPoolLeaseListResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Add a nil check when handling ProviderData because Terraform
+	// sets that data after it calls the ConfigureProvider RPC.
+	if req.ProviderData == nil {
+		return
+	}
+
+	baseClient, ok := req.ProviderData.(*BaseClient)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected List Resource Configure Type",
+			fmt.Sprintf("Expected *provider.BaseClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.baseClient = baseClient
+}
+
+// ValidateListResourceConfig refuses to list every subscription of the tenant. Importing all of them would hand
+// subscriptions that were never leased to Terraform, and destroying one would move it into the pool.
+func (r *subscriptionPoolLeaseListResource) ValidateListResourceConfig(ctx context.Context, req list.ValidateConfigRequest, resp *list.ValidateConfigResponse) {
+	var config subscriptionPoolLeaseListResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(config.validateFilter()...)
+}
+
+// validateFilter requires a management group or a name prefix. Unknown values are accepted, they are checked once known.
+func (m *subscriptionPoolLeaseListResourceModel) validateFilter() diag.Diagnostics {
+	var diags diag.Diagnostics
+	if m.ManagementGroupName.IsUnknown() || m.SubscriptionNamePrefix.IsUnknown() {
+		return diags
+	}
+	if m.ManagementGroupName.ValueString() == "" && m.SubscriptionNamePrefix.ValueString() == "" {
+		diags.AddAttributeError(
+			path.Root("management_group_name"),
+			"Missing lease filter",
+			"Set management_group_name or subscription_name_prefix. Leased subscriptions carry no marker of their own, "+
+				"so without a filter every subscription of the tenant outside of the pool would be listed, including subscriptions that were never leased.",
+		)
+	}
+	return diags
+}
+
+// List streams every leased subscription matching the configured filters.
+func (r *subscriptionPoolLeaseListResource) List(ctx context.Context, req list.ListRequest, stream *list.ListResultsStream) {
+	var config subscriptionPoolLeaseListResourceModel
+	diags := req.Config.Get(ctx, &config)
+	if diags.HasError() {
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	diags.Append(config.validateFilter()...)
+	if diags.HasError() {
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	pool, poolDiags := r.baseClient.Pool(ctx, config.Pool.ValueString())
+	diags.Append(poolDiags...)
+	if diags.HasError() {
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	subscriptions, err := pool.ListSubscriptionStates(ctx, config.ManagementGroupName.ValueString())
+	if err != nil {
+		diags.Append(errorDiagnostic("Failed while listing subscriptions", err))
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	stream.Results = func(push func(list.ListResult) bool) {
+		var count int64
+		for _, subscription := range subscriptions {
+			if req.Limit > 0 && count >= req.Limit {
+				return
+			}
+
+			if pool.isInPool(subscription.parentManagementGroup, subscription.displayName) {
+				continue
+			}
+			if config.ManagementGroupName.ValueString() != "" && !strings.EqualFold(subscription.parentManagementGroup, config.ManagementGroupName.ValueString()) {
+				continue
+			}
+			if config.SubscriptionNamePrefix.ValueString() != "" && !strings.HasPrefix(subscription.displayName, config.SubscriptionNamePrefix.ValueString()) {
+				continue
+			}
+
+			result := req.NewListResult(ctx)
+			result.DisplayName = subscription.displayName
+
+			identity := subscriptionPoolLeaseResourceIdentityModel{
+				SubscriptionId: types.StringValue(subscription.subscriptionId),
+				Pool:           config.Pool,
+			}
+			result.Diagnostics.Append(result.Identity.Set(ctx, identity)...)
+
+			if req.IncludeResource {
+				lease := subscriptionPoolLeaseResourceModel{
+					TargetManagementGroupName:    types.StringValue(subscription.parentManagementGroup),
+					TargetSubscriptionName:       types.StringValue(subscription.displayName),
+					SubscriptionId:               types.StringValue(subscription.subscriptionId),
+					QualifiedSubscriptionId:      types.StringValue(subscriptionIdPrefix + subscription.subscriptionId),
+					FullyQualifiedSubscriptionId: types.StringValue(managementGroupIdPrefix + subscription.parentManagementGroup + subscriptionIdPrefix + subscription.subscriptionId),
+					ActualParentManagementGroup:  types.StringValue(subscription.parentManagementGroup),
+					Pool:                         config.Pool,
+					Timeouts:                     nullTimeouts(),
+				}
+				result.Diagnostics.Append(result.Resource.Set(ctx, lease)...)
+			}
+
+			count++
+			if !push(result) {
+				return
+			}
+		}
+	}
+}
@@ -2,11 +2,16 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/identityschema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
@@ -18,6 +23,16 @@ var (
 	_ resource.Resource                = &subscriptionPoolLeaseResource{}
 	_ resource.ResourceWithConfigure   = &subscriptionPoolLeaseResource{}
 	_ resource.ResourceWithImportState = &subscriptionPoolLeaseResource{}
+	_ resource.ResourceWithIdentity    = &subscriptionPoolLeaseResource{}
+	_ resource.ResourceWithModifyPlan  = &subscriptionPoolLeaseResource{}
+)
+
+// Default timeouts of the lease operations, overridable with the timeouts block.
+const (
+	defaultCreateTimeout = 30 * time.Minute
+	defaultReadTimeout   = 5 * time.Minute
+	defaultUpdateTimeout = 30 * time.Minute
+	defaultDeleteTimeout = 30 * time.Minute
 )
 
 // NewSubscriptionPoolResource is a helper function to simplify the provider implementation.
@@ -31,22 +46,33 @@ type subscriptionPoolLeaseResource struct {
 }
 
 type subscriptionPoolLeaseResourceModel struct {
-	TargetManagementGroupName    types.String `tfsdk:"target_management_group_name"`
-	TargetSubscriptionName       types.String `tfsdk:"target_subscription_name"`
-	SubscriptionId               types.String `tfsdk:"subscription_id"`
-	QualifiedSubscriptionId      types.String `tfsdk:"qualified_subscription_id"`
-	FullyQualifiedSubscriptionId types.String `tfsdk:"fully_qualified_subscription_id"`
-	ActualParentManagementGroup  types.String `tfsdk:"actual_parant_management_group"`
+	TargetManagementGroupName    types.String   `tfsdk:"target_management_group_name"`
+	TargetSubscriptionName       types.String   `tfsdk:"target_subscription_name"`
+	SubscriptionId               types.String   `tfsdk:"subscription_id"`
+	QualifiedSubscriptionId      types.String   `tfsdk:"qualified_subscription_id"`
+	FullyQualifiedSubscriptionId types.String   `tfsdk:"fully_qualified_subscription_id"`
+	ActualParentManagementGroup  types.String   `tfsdk:"actual_parent_management_group"`
+	Pool                         types.String   `tfsdk:"pool"`
+	Timeouts                     timeouts.Value `tfsdk:"timeouts"`
+}
+
+// subscriptionPoolLeaseResourceIdentityModel identifies a lease by the leased subscription and the pool it was leased from.
+type subscriptionPoolLeaseResourceIdentityModel struct {
+	SubscriptionId types.String `tfsdk:"subscription_id"`
+	Pool           types.String `tfsdk:"pool"`
 }
 
 // Metadata returns the resource type name.
 func (r *subscriptionPoolLeaseResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_subscription_pool_lease"
+	// ModifyPlan doesn't need the client, so plans stay precise while the provider configuration is deferred.
+	resp.ResourceBehavior.ProviderDeferred.EnablePlanModification = true
 }
 
 // Schema defines the schema for the resource.
-func (r *subscriptionPoolLeaseResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *subscriptionPoolLeaseResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
+		Version: 1,
 		Attributes: map[string]schema.Attribute{
 			"target_management_group_name": schema.StringAttribute{
 				Description: "the ID; either a GUID or a named ID",
@@ -77,10 +103,41 @@ func (r *subscriptionPoolLeaseResource) Schema(_ context.Context, _ resource.Sch
 					stringplanmodifier.UseStateForUnknown(),
 				},
 			},
-			"actual_parant_management_group": schema.StringAttribute{
+			"actual_parent_management_group": schema.StringAttribute{
 				Description: "like: /providers/Microsoft.Management/managementGroups/00000000-0000-0000-0000-000000000000",
 				Computed:    true,
 			},
+			"pool": schema.StringAttribute{
+				Description: "the name of a pool from the provider's pools; the provider's default pool if unset",
+				Optional:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+// IdentitySchema defines the identity schema for the resource.
+func (r *subscriptionPoolLeaseResource) IdentitySchema(_ context.Context, _ resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = identityschema.Schema{
+		Attributes: map[string]identityschema.Attribute{
+			"subscription_id": identityschema.StringAttribute{
+				Description:       "like: 00000000-0000-0000-0000-000000000000",
+				RequiredForImport: true,
+			},
+			"pool": identityschema.StringAttribute{
+				Description:       "the name of a pool from the provider's pools; the provider's default pool if unset",
+				OptionalForImport: true,
+			},
 		},
 	}
 }
@@ -107,6 +164,27 @@ func (r *subscriptionPoolLeaseResource) Configure(_ context.Context, req resourc
 	r.baseClient = baseClient
 }
 
+// ModifyPlan defers leasing a subscription as long as the pool to lease from is unknown.
+func (r *subscriptionPoolLeaseResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to decide on destroy.
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var pool types.String
+	diags := req.Plan.GetAttribute(ctx, path.Root("pool"), &pool)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if pool.IsUnknown() && req.ClientCapabilities.DeferralAllowed {
+		resp.Deferred = &resource.Deferred{
+			Reason: resource.DeferredReasonResourceConfigUnknown,
+		}
+	}
+}
+
 // Create a new resource.
 func (r *subscriptionPoolLeaseResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	// Retrieve values from plan
@@ -117,22 +195,30 @@ func (r *subscriptionPoolLeaseResource) Create(ctx context.Context, req resource
 		return
 	}
 
-	subscriptionId := <-r.baseClient.availableSubscriptions
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
 
-	if subscriptionId == "" {
-		resp.Diagnostics.AddError(
-			"Didn't find any available Subscription",
-			fmt.Sprintf("Searched for subscriptions with prefix '%s' in ManagementGroup '%s'", r.baseClient.poolSubscriptionPrefix, r.baseClient.poolManagementGroupId),
-		)
+	pool, diags := r.baseClient.Pool(ctx, plan.Pool.ValueString())
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	subscriptionId, err := pool.NextAvailableSubscription(ctx)
+	if err != nil {
+		resp.Diagnostics.Append(errorDiagnostic("Failed while fetching subsciption pool content", err))
 		return
 	}
 
 	// Associate Subscription
-	associationResponse, err := r.baseClient.MoveSubscription(subscriptionId, plan.TargetManagementGroupName.ValueString())
+	associationResponse, err := pool.MoveSubscription(ctx, subscriptionId, pool.poolManagementGroupId, plan.TargetManagementGroupName.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error moving subscription", err.Error(),
-		)
+		resp.Diagnostics.Append(errorDiagnostic("Error moving subscription", err))
 		return
 	}
 	plan.ActualParentManagementGroup = types.StringValue(plan.TargetManagementGroupName.ValueString())
@@ -140,20 +226,35 @@ func (r *subscriptionPoolLeaseResource) Create(ctx context.Context, req resource
 	plan.QualifiedSubscriptionId = types.StringValue(strings.TrimPrefix(*associationResponse.ID, *associationResponse.Properties.Parent.ID))
 	plan.FullyQualifiedSubscriptionId = types.StringValue(*associationResponse.ID)
 
-	_, err = r.baseClient.RenameSubscription(subscriptionId, plan.TargetSubscriptionName.ValueString())
+	_, err = pool.RenameSubscription(ctx, subscriptionId, plan.TargetSubscriptionName.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error renaming subscription", err.Error(),
-		)
+		resp.Diagnostics.Append(errorDiagnostic("Error renaming subscription", err))
 		return
 	}
 
+	err = pool.WaitForSubscription(ctx, subscriptionId, plan.TargetManagementGroupName.ValueString(), plan.TargetSubscriptionName.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddWarning(
+			"Lease not yet visible in Azure",
+			fmt.Sprintf("The subscription was leased, but Azure doesn't report the change yet. Refreshes keep the leased values for %s.\n\n%s", propagationWindow, err.Error()),
+		)
+	}
+	diags = setPendingLeaseChange(ctx, resp.Private, plan.TargetManagementGroupName.ValueString(), plan.TargetSubscriptionName.ValueString())
+	resp.Diagnostics.Append(diags...)
+
 	// Set state to fully populated data
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
+
+	identity := subscriptionPoolLeaseResourceIdentityModel{
+		SubscriptionId: plan.SubscriptionId,
+		Pool:           plan.Pool,
+	}
+	diags = resp.Identity.Set(ctx, identity)
+	resp.Diagnostics.Append(diags...)
 }
 
 // Read refreshes the Terraform state with the latest data.
@@ -166,19 +267,69 @@ func (r *subscriptionPoolLeaseResource) Read(ctx context.Context, req resource.R
 		return
 	}
 
-	matchingEntity, err := r.baseClient.ReadSubscriptionState(state.SubscriptionId.ValueString())
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	pool, diags := r.baseClient.Pool(ctx, state.Pool.ValueString())
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	pendingChange, diags := getPendingLeaseChange(ctx, req.Private)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	subscription, err := pool.ReadSubscriptionState(ctx, state.SubscriptionId.ValueString(), state.ActualParentManagementGroup.ValueString())
+	var notFound NoSubscriptionsFoundError
+	subscriptionGone := errors.As(err, &notFound) || isNotFound(err)
+	if pendingChange != nil {
+		if subscriptionGone || (err == nil && !pendingChange.isObservedIn(subscription.parentManagementGroup, subscription.displayName)) {
+			// Azure hasn't caught up with our last change yet, keep the state we wrote.
+			return
+		}
+	}
+	if subscriptionGone {
+		resp.Diagnostics.AddWarning(
+			"Leased subscription no longer exists",
+			fmt.Sprintf("Subscription '%s' was not found in the management group hierarchy, it was probably cancelled or deleted. "+
+				"The lease is removed from the state and will be recreated with another subscription from the pool.", state.SubscriptionId.ValueString()),
+		)
+		resp.State.RemoveResource(ctx)
+		return
+	}
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Couldn't find managed subscription",
-			err.Error(),
+		resp.Diagnostics.Append(errorDiagnostic("Couldn't find managed subscription", err))
+		return
+	}
+
+	// Someone returned the subscription to the pool behind our back, so another workspace may lease it any time.
+	// Give it up instead of fighting over it; the next apply leases a replacement.
+	if pool.isInPool(subscription.parentManagementGroup, subscription.displayName) && !pool.isInPool(state.TargetManagementGroupName.ValueString(), state.TargetSubscriptionName.ValueString()) {
+		resp.Diagnostics.AddWarning(
+			"Subscription lease lost",
+			fmt.Sprintf("Subscription '%s' is back in the subscription pool as '%s' under ManagementGroup '%s', it was returned outside of Terraform. "+
+				"The lease is removed from the state and will be recreated with another subscription from the pool.", subscription.subscriptionId, subscription.displayName, subscription.parentManagementGroup),
 		)
+		resp.State.RemoveResource(ctx)
 		return
 	}
-	state.ActualParentManagementGroup = types.StringValue(strings.TrimPrefix(*matchingEntity.Properties.Parent.ID, "/providers/Microsoft.Management/managementGroups/"))
-	state.TargetSubscriptionName = types.StringValue(*matchingEntity.Properties.DisplayName)
-	state.SubscriptionId = types.StringValue(*matchingEntity.Name)
-	state.SubscriptionId = types.StringValue(*matchingEntity.ID)
-	state.FullyQualifiedSubscriptionId = types.StringValue(*matchingEntity.Properties.Parent.ID + *matchingEntity.ID)
+	state.ActualParentManagementGroup = types.StringValue(subscription.parentManagementGroup)
+	state.TargetSubscriptionName = types.StringValue(subscription.displayName)
+	state.SubscriptionId = types.StringValue(subscription.subscriptionId)
+	state.QualifiedSubscriptionId = types.StringValue(subscriptionIdPrefix + subscription.subscriptionId)
+	state.FullyQualifiedSubscriptionId = types.StringValue(managementGroupIdPrefix + subscription.parentManagementGroup + subscriptionIdPrefix + subscription.subscriptionId)
+
+	// The last change is visible now, or we stop waiting for it.
+	diags = resp.Private.SetKey(ctx, pendingLeaseChangePrivateKey, nil)
+	resp.Diagnostics.Append(diags...)
 
 	// Set refreshed state
 	diags = resp.State.Set(ctx, &state)
@@ -186,6 +337,13 @@ func (r *subscriptionPoolLeaseResource) Read(ctx context.Context, req resource.R
 	if resp.Diagnostics.HasError() {
 		return
 	}
+
+	identity := subscriptionPoolLeaseResourceIdentityModel{
+		SubscriptionId: types.StringValue(subscription.subscriptionId),
+		Pool:           state.Pool,
+	}
+	diags = resp.Identity.Set(ctx, identity)
+	resp.Diagnostics.Append(diags...)
 }
 
 // Update updates the resource and sets the updated Terraform state on success.
@@ -204,13 +362,29 @@ func (r *subscriptionPoolLeaseResource) Update(ctx context.Context, req resource
 		return
 	}
 
-	sub, err := r.baseClient.managementGroupClientFactory.NewManagementGroupSubscriptionsClient().GetSubscription(context.Background(), state.ActualParentManagementGroup.ValueString(), state.SubscriptionId.ValueString(), nil)
+	updateTimeout, diags := plan.Timeouts.Update(ctx, defaultUpdateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	pool, diags := r.baseClient.Pool(ctx, state.Pool.ValueString())
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	sub, err := pool.GetSubscription(ctx, state.ActualParentManagementGroup.ValueString(), state.SubscriptionId.ValueString())
+	if isNotFound(err) {
+		err = fmt.Errorf("subscription '%s' is not under ManagementGroup '%s', it was moved or deleted outside of Terraform; "+
+			"run `terraform refresh` or plan again to pick up its current state: %w", state.SubscriptionId.ValueString(), state.ActualParentManagementGroup.ValueString(), pool.classifyError(err))
+		resp.Diagnostics.Append(errorDiagnostic("Broken State", err))
+		return
+	}
 	if err != nil {
-		//TODO check for 404 or different error
-		resp.Diagnostics.AddError(
-			"Broken State",
-			fmt.Sprintf("Could not find Subscription '%s' under ManagementGroup '%s'\nAzure API Error: %s", state.SubscriptionId.ValueString(), state.ActualParentManagementGroup.ValueString(), err.Error()),
-		)
+		resp.Diagnostics.Append(errorDiagnostic("Error reading Subscription", pool.classifyError(err)))
 		return
 	}
 	plan.SubscriptionId = types.StringValue(*sub.Name)
@@ -218,35 +392,47 @@ func (r *subscriptionPoolLeaseResource) Update(ctx context.Context, req resource
 	plan.FullyQualifiedSubscriptionId = types.StringValue(*sub.ID)
 
 	if state.ActualParentManagementGroup.ValueString() != plan.TargetManagementGroupName.ValueString() {
-		_, err := r.baseClient.MoveSubscription(plan.TargetManagementGroupName.ValueString(), *sub.Name)
+		_, err := pool.MoveSubscription(ctx, *sub.Name, state.ActualParentManagementGroup.ValueString(), plan.TargetManagementGroupName.ValueString())
 		if err != nil {
-			resp.Diagnostics.AddError(
-				"Error during Subscription Move",
-				err.Error(),
-			)
+			resp.Diagnostics.Append(errorDiagnostic("Error during Subscription Move", err))
 			return
 		}
 	}
 	plan.ActualParentManagementGroup = types.StringValue(plan.TargetManagementGroupName.ValueString())
 
 	if plan.TargetSubscriptionName.ValueString() != *sub.Properties.DisplayName {
-		_, err := r.baseClient.RenameSubscription(state.SubscriptionId.ValueString(), plan.TargetSubscriptionName.ValueString())
+		_, err := pool.RenameSubscription(ctx, state.SubscriptionId.ValueString(), plan.TargetSubscriptionName.ValueString())
 		if err != nil {
-			//TODO handle retry on 429
-			resp.Diagnostics.AddError(
-				"Error during Subscription Rename",
-				err.Error(),
-			)
+			resp.Diagnostics.Append(errorDiagnostic("Error during Subscription Rename", err))
 			return
 		}
 		plan.TargetSubscriptionName = types.StringValue(plan.TargetSubscriptionName.ValueString())
 	}
 
+	if state.ActualParentManagementGroup.ValueString() != plan.TargetManagementGroupName.ValueString() || plan.TargetSubscriptionName.ValueString() != *sub.Properties.DisplayName {
+		err = pool.WaitForSubscription(ctx, *sub.Name, plan.TargetManagementGroupName.ValueString(), plan.TargetSubscriptionName.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddWarning(
+				"Lease changes not yet visible in Azure",
+				fmt.Sprintf("The subscription was updated, but Azure doesn't report the change yet. Refreshes keep the updated values for %s.\n\n%s", propagationWindow, err.Error()),
+			)
+		}
+		diags = setPendingLeaseChange(ctx, resp.Private, plan.TargetManagementGroupName.ValueString(), plan.TargetSubscriptionName.ValueString())
+		resp.Diagnostics.Append(diags...)
+	}
+
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
+
+	identity := subscriptionPoolLeaseResourceIdentityModel{
+		SubscriptionId: plan.SubscriptionId,
+		Pool:           plan.Pool,
+	}
+	diags = resp.Identity.Set(ctx, identity)
+	resp.Diagnostics.Append(diags...)
 }
 
 // Delete deletes the resource and removes the Terraform state on success.
@@ -258,31 +444,102 @@ func (r *subscriptionPoolLeaseResource) Delete(ctx context.Context, req resource
 		return
 	}
 
-	_, err := r.baseClient.MoveSubscription(state.SubscriptionId.ValueString(), r.baseClient.poolManagementGroupId)
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	pool, diags := r.baseClient.Pool(ctx, state.Pool.ValueString())
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	_, err := pool.MoveSubscription(ctx, state.SubscriptionId.ValueString(), state.ActualParentManagementGroup.ValueString(), pool.poolManagementGroupId)
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error during Subscription Move",
-			err.Error(),
-		)
+		resp.Diagnostics.Append(errorDiagnostic("Error during Subscription Move", err))
+		return
+	}
+
+	newSubscriptionName := truncateString(pool.poolSubscriptionPrefix+state.SubscriptionId.ValueString(), 64)
+	_, err = pool.RenameSubscription(ctx, state.SubscriptionId.ValueString(), newSubscriptionName)
+	if err != nil {
+		resp.Diagnostics.Append(errorDiagnostic("Error during Subscription Rename", err))
 		return
 	}
 
-	newSubscriptionName := truncateString(r.baseClient.poolSubscriptionPrefix+state.SubscriptionId.ValueString(), 64)
-	_, err = r.baseClient.RenameSubscription(state.SubscriptionId.ValueString(), newSubscriptionName)
+	// Wait until the subscription is available again, so it can be leased right away.
+	err = pool.WaitForSubscription(ctx, state.SubscriptionId.ValueString(), pool.poolManagementGroupId, newSubscriptionName)
 	if err != nil {
+		resp.Diagnostics.AddWarning(
+			"Returned subscription not yet visible in Azure",
+			fmt.Sprintf("The subscription was returned to the pool, but Azure doesn't report the change yet.\n\n%s", err.Error()),
+		)
+	}
+}
+
+// ImportState imports a lease by its identity, or by an import ID of the form "subscription_id" for the default pool
+// or "pool/subscription_id" for a named pool. The pool decides the tenant Read looks the subscription up in.
+func (r *subscriptionPoolLeaseResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	identity := subscriptionPoolLeaseResourceIdentityModel{
+		SubscriptionId: types.StringNull(),
+		Pool:           types.StringNull(),
+	}
+	if req.ID != "" {
+		pool, subscriptionId := "", strings.TrimPrefix(req.ID, subscriptionIdPrefix)
+		if i := strings.LastIndex(subscriptionId, "/"); i >= 0 {
+			pool, subscriptionId = subscriptionId[:i], subscriptionId[i+1:]
+			if pool == "" {
+				resp.Diagnostics.AddError(
+					"Unexpected Import Identifier",
+					fmt.Sprintf("Expected an import identifier of the form subscription_id or pool/subscription_id, got: %q", req.ID),
+				)
+				return
+			}
+			identity.Pool = types.StringValue(pool)
+		}
+		identity.SubscriptionId = types.StringValue(subscriptionId)
+	} else {
+		resp.Diagnostics.Append(req.Identity.Get(ctx, &identity)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if identity.SubscriptionId.ValueString() == "" {
 		resp.Diagnostics.AddError(
-			"Error during Subscription Rename",
-			err.Error(),
+			"Missing Subscription ID",
+			"The import identifier or identity doesn't contain a subscription_id.",
 		)
 		return
 	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("subscription_id"), identity.SubscriptionId)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("pool"), identity.Pool)...)
+	if resp.Identity != nil {
+		resp.Diagnostics.Append(resp.Identity.Set(ctx, identity)...)
+	}
 }
 
-func (r *subscriptionPoolLeaseResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	// Retrieve import ID and save to id attribute
-	resource.ImportStatePassthroughID(ctx, path.Root("subscription_id"), req, resp)
+// nullTimeouts is the timeouts value of states that are not derived from a configuration, e.g. moved or listed leases.
+func nullTimeouts() timeouts.Value {
+	return timeouts.Value{
+		Object: types.ObjectNull(map[string]attr.Type{
+			"create": types.StringType,
+			"read":   types.StringType,
+			"update": types.StringType,
+			"delete": types.StringType,
+		}),
+	}
 }
 
+// truncateString cuts s to at most maxLength bytes.
 func truncateString(s string, maxLength int) string {
+	if len(s) <= maxLength {
+		return s
+	}
 	return s[:maxLength]
 }
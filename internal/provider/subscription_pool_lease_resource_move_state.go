@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ resource.ResourceWithMoveState = &subscriptionPoolLeaseResource{}
+
+const (
+	azurermProviderAddressSuffix = "hashicorp/azurerm"
+	managementGroupIdPrefix      = "/providers/Microsoft.Management/managementGroups/"
+	subscriptionIdPrefix         = "/subscriptions/"
+)
+
+// azurermManagementGroupSubscriptionAssociationState holds the attributes we need from azurerm_management_group_subscription_association.
+type azurermManagementGroupSubscriptionAssociationState struct {
+	Id                string `json:"id"`
+	ManagementGroupId string `json:"management_group_id"`
+	SubscriptionId    string `json:"subscription_id"`
+}
+
+// azurermSubscriptionState holds the attributes we need from azurerm_subscription.
+type azurermSubscriptionState struct {
+	SubscriptionId   string `json:"subscription_id"`
+	SubscriptionName string `json:"subscription_name"`
+}
+
+// MoveState allows moved blocks to convert azurerm subscription resources into a lease.
+func (r *subscriptionPoolLeaseResource) MoveState(_ context.Context) []resource.StateMover {
+	return []resource.StateMover{
+		{
+			StateMover: moveStateFromAzurermManagementGroupSubscriptionAssociation,
+		},
+		{
+			StateMover: moveStateFromAzurermSubscription,
+		},
+	}
+}
+
+func moveStateFromAzurermManagementGroupSubscriptionAssociation(ctx context.Context, req resource.MoveStateRequest, resp *resource.MoveStateResponse) {
+	if req.SourceTypeName != "azurerm_management_group_subscription_association" || !strings.HasSuffix(req.SourceProviderAddress, azurermProviderAddressSuffix) {
+		return
+	}
+
+	var source azurermManagementGroupSubscriptionAssociationState
+	if err := json.Unmarshal(req.SourceRawState.JSON, &source); err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to Move Resource State",
+			fmt.Sprintf("Could not read the azurerm_management_group_subscription_association state: %s", err.Error()),
+		)
+		return
+	}
+
+	subscriptionId := strings.TrimPrefix(source.SubscriptionId, subscriptionIdPrefix)
+	managementGroupName := strings.TrimPrefix(source.ManagementGroupId, managementGroupIdPrefix)
+
+	target := subscriptionPoolLeaseResourceModel{
+		TargetManagementGroupName:    types.StringValue(managementGroupName),
+		TargetSubscriptionName:       types.StringNull(),
+		SubscriptionId:               types.StringValue(subscriptionId),
+		QualifiedSubscriptionId:      types.StringValue(subscriptionIdPrefix + subscriptionId),
+		FullyQualifiedSubscriptionId: types.StringValue(managementGroupIdPrefix + managementGroupName + subscriptionIdPrefix + subscriptionId),
+		ActualParentManagementGroup:  types.StringValue(managementGroupName),
+		Timeouts:                     nullTimeouts(),
+	}
+	setMovedSubscriptionPoolLeaseState(ctx, target, resp)
+}
+
+func moveStateFromAzurermSubscription(ctx context.Context, req resource.MoveStateRequest, resp *resource.MoveStateResponse) {
+	if req.SourceTypeName != "azurerm_subscription" || !strings.HasSuffix(req.SourceProviderAddress, azurermProviderAddressSuffix) {
+		return
+	}
+
+	var source azurermSubscriptionState
+	if err := json.Unmarshal(req.SourceRawState.JSON, &source); err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to Move Resource State",
+			fmt.Sprintf("Could not read the azurerm_subscription state: %s", err.Error()),
+		)
+		return
+	}
+
+	// azurerm_subscription does not know the management group; the next refresh fills it in.
+	target := subscriptionPoolLeaseResourceModel{
+		TargetManagementGroupName:    types.StringNull(),
+		TargetSubscriptionName:       types.StringValue(source.SubscriptionName),
+		SubscriptionId:               types.StringValue(source.SubscriptionId),
+		QualifiedSubscriptionId:      types.StringValue(subscriptionIdPrefix + source.SubscriptionId),
+		FullyQualifiedSubscriptionId: types.StringNull(),
+		ActualParentManagementGroup:  types.StringNull(),
+		Timeouts:                     nullTimeouts(),
+	}
+	setMovedSubscriptionPoolLeaseState(ctx, target, resp)
+}
+
+func setMovedSubscriptionPoolLeaseState(ctx context.Context, target subscriptionPoolLeaseResourceModel, resp *resource.MoveStateResponse) {
+	if target.SubscriptionId.ValueString() == "" {
+		resp.Diagnostics.AddError(
+			"Unable to Move Resource State",
+			"The source state does not contain a subscription_id.",
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.TargetState.Set(ctx, target)...)
+	if resp.Diagnostics.HasError() || resp.TargetIdentity == nil {
+		return
+	}
+
+	identity := subscriptionPoolLeaseResourceIdentityModel{
+		SubscriptionId: target.SubscriptionId,
+	}
+	resp.Diagnostics.Append(resp.TargetIdentity.Set(ctx, identity)...)
+}
@@ -0,0 +1,169 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// newSubscriptionPoolLeaseIdentity returns an empty identity of the lease, ready to be set by a mover.
+func newSubscriptionPoolLeaseIdentity(t *testing.T) *tfsdk.ResourceIdentity {
+	t.Helper()
+	ctx := context.Background()
+
+	var resp resource.IdentitySchemaResponse
+	(&subscriptionPoolLeaseResource{}).IdentitySchema(ctx, resource.IdentitySchemaRequest{}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected identity schema diagnostics: %v", resp.Diagnostics)
+	}
+	return &tfsdk.ResourceIdentity{
+		Schema: resp.IdentitySchema,
+		Raw:    tftypes.NewValue(resp.IdentitySchema.Type().TerraformType(ctx), nil),
+	}
+}
+
+func moveSubscriptionPoolLeaseState(t *testing.T, mover func(context.Context, resource.MoveStateRequest, *resource.MoveStateResponse), sourceTypeName string, sourceProviderAddress string, sourceState string) resource.MoveStateResponse {
+	t.Helper()
+
+	req := resource.MoveStateRequest{
+		SourceTypeName:        sourceTypeName,
+		SourceProviderAddress: sourceProviderAddress,
+		SourceRawState:        &tfprotov6.RawState{JSON: []byte(sourceState)},
+	}
+	resp := resource.MoveStateResponse{
+		TargetState:    newSubscriptionPoolLeaseState(t),
+		TargetIdentity: newSubscriptionPoolLeaseIdentity(t),
+	}
+	mover(context.Background(), req, &resp)
+	return resp
+}
+
+func TestMoveStateFromAzurermManagementGroupSubscriptionAssociation(t *testing.T) {
+	resp := moveSubscriptionPoolLeaseState(t,
+		moveStateFromAzurermManagementGroupSubscriptionAssociation,
+		"azurerm_management_group_subscription_association",
+		"registry.terraform.io/hashicorp/azurerm",
+		`{
+			"id": "/providers/Microsoft.Management/managementGroups/landing-zones/subscriptions/00000000-0000-0000-0000-000000000001",
+			"management_group_id": "/providers/Microsoft.Management/managementGroups/landing-zones",
+			"subscription_id": "/subscriptions/00000000-0000-0000-0000-000000000001"
+		}`,
+	)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+	}
+
+	lease := getSubscriptionPoolLeaseState(t, resp.TargetState)
+	if got, want := lease.SubscriptionId.ValueString(), "00000000-0000-0000-0000-000000000001"; got != want {
+		t.Errorf("subscription_id = %q, want %q", got, want)
+	}
+	if got, want := lease.QualifiedSubscriptionId.ValueString(), "/subscriptions/00000000-0000-0000-0000-000000000001"; got != want {
+		t.Errorf("qualified_subscription_id = %q, want %q", got, want)
+	}
+	if got, want := lease.FullyQualifiedSubscriptionId.ValueString(), "/providers/Microsoft.Management/managementGroups/landing-zones/subscriptions/00000000-0000-0000-0000-000000000001"; got != want {
+		t.Errorf("fully_qualified_subscription_id = %q, want %q", got, want)
+	}
+	if got, want := lease.TargetManagementGroupName.ValueString(), "landing-zones"; got != want {
+		t.Errorf("target_management_group_name = %q, want %q", got, want)
+	}
+	if got, want := lease.ActualParentManagementGroup.ValueString(), "landing-zones"; got != want {
+		t.Errorf("actual_parent_management_group = %q, want %q", got, want)
+	}
+	if !lease.TargetSubscriptionName.IsNull() {
+		t.Errorf("target_subscription_name = %q, want null", lease.TargetSubscriptionName.ValueString())
+	}
+
+	var identity subscriptionPoolLeaseResourceIdentityModel
+	if diags := resp.TargetIdentity.Get(context.Background(), &identity); diags.HasError() {
+		t.Fatalf("unexpected diagnostics reading the identity: %v", diags)
+	}
+	if got, want := identity.SubscriptionId.ValueString(), "00000000-0000-0000-0000-000000000001"; got != want {
+		t.Errorf("identity subscription_id = %q, want %q", got, want)
+	}
+}
+
+func TestMoveStateFromAzurermSubscription(t *testing.T) {
+	resp := moveSubscriptionPoolLeaseState(t,
+		moveStateFromAzurermSubscription,
+		"azurerm_subscription",
+		"registry.terraform.io/hashicorp/azurerm",
+		`{
+			"id": "/providers/Microsoft.Subscription/aliases/team-a",
+			"subscription_id": "00000000-0000-0000-0000-000000000002",
+			"subscription_name": "team-a"
+		}`,
+	)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+	}
+
+	lease := getSubscriptionPoolLeaseState(t, resp.TargetState)
+	if got, want := lease.SubscriptionId.ValueString(), "00000000-0000-0000-0000-000000000002"; got != want {
+		t.Errorf("subscription_id = %q, want %q", got, want)
+	}
+	if got, want := lease.QualifiedSubscriptionId.ValueString(), "/subscriptions/00000000-0000-0000-0000-000000000002"; got != want {
+		t.Errorf("qualified_subscription_id = %q, want %q", got, want)
+	}
+	if got, want := lease.TargetSubscriptionName.ValueString(), "team-a"; got != want {
+		t.Errorf("target_subscription_name = %q, want %q", got, want)
+	}
+	// azurerm_subscription doesn't know the management group, the next refresh fills it in.
+	if !lease.TargetManagementGroupName.IsNull() || !lease.ActualParentManagementGroup.IsNull() || !lease.FullyQualifiedSubscriptionId.IsNull() {
+		t.Errorf("management group attributes = %q, %q, %q, want null", lease.TargetManagementGroupName.ValueString(), lease.ActualParentManagementGroup.ValueString(), lease.FullyQualifiedSubscriptionId.ValueString())
+	}
+}
+
+func TestMoveStateIgnoresOtherSources(t *testing.T) {
+	testCases := map[string]struct {
+		mover                 func(context.Context, resource.MoveStateRequest, *resource.MoveStateResponse)
+		sourceTypeName        string
+		sourceProviderAddress string
+	}{
+		"association of another provider": {
+			mover:                 moveStateFromAzurermManagementGroupSubscriptionAssociation,
+			sourceTypeName:        "azurerm_management_group_subscription_association",
+			sourceProviderAddress: "registry.terraform.io/example/azurerm-fork",
+		},
+		"other azurerm resource for the association mover": {
+			mover:                 moveStateFromAzurermManagementGroupSubscriptionAssociation,
+			sourceTypeName:        "azurerm_subscription",
+			sourceProviderAddress: "registry.terraform.io/hashicorp/azurerm",
+		},
+		"other azurerm resource for the subscription mover": {
+			mover:                 moveStateFromAzurermSubscription,
+			sourceTypeName:        "azurerm_resource_group",
+			sourceProviderAddress: "registry.terraform.io/hashicorp/azurerm",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			resp := moveSubscriptionPoolLeaseState(t, testCase.mover, testCase.sourceTypeName, testCase.sourceProviderAddress, `{"subscription_id": "00000000-0000-0000-0000-000000000003"}`)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+			if !resp.TargetState.Raw.IsNull() {
+				t.Errorf("target state = %v, want it untouched", resp.TargetState.Raw)
+			}
+		})
+	}
+}
+
+func TestMoveStateRequiresSubscriptionId(t *testing.T) {
+	resp := moveSubscriptionPoolLeaseState(t,
+		moveStateFromAzurermSubscription,
+		"azurerm_subscription",
+		"registry.terraform.io/hashicorp/azurerm",
+		`{"subscription_name": "team-a"}`,
+	)
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected an error for a source state without subscription_id")
+	}
+	if !resp.TargetState.Raw.IsNull() {
+		t.Errorf("target state = %v, want it untouched", resp.TargetState.Raw)
+	}
+}
@@ -0,0 +1,107 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestSubscriptionPoolLeaseImportState(t *testing.T) {
+	testCases := map[string]struct {
+		id       string
+		identity *subscriptionPoolLeaseResourceIdentityModel
+
+		expectedSubscriptionId string
+		expectedPool           string
+		expectError            bool
+	}{
+		"id of the default pool": {
+			id:                     "00000000-0000-0000-0000-000000000001",
+			expectedSubscriptionId: "00000000-0000-0000-0000-000000000001",
+		},
+		"qualified id of the default pool": {
+			id:                     "/subscriptions/00000000-0000-0000-0000-000000000001",
+			expectedSubscriptionId: "00000000-0000-0000-0000-000000000001",
+		},
+		"id of a named pool": {
+			id:                     "sandbox/00000000-0000-0000-0000-000000000002",
+			expectedSubscriptionId: "00000000-0000-0000-0000-000000000002",
+			expectedPool:           "sandbox",
+		},
+		"identity of a named pool": {
+			identity: &subscriptionPoolLeaseResourceIdentityModel{
+				SubscriptionId: types.StringValue("00000000-0000-0000-0000-000000000003"),
+				Pool:           types.StringValue("sandbox"),
+			},
+			expectedSubscriptionId: "00000000-0000-0000-0000-000000000003",
+			expectedPool:           "sandbox",
+		},
+		"identity of the default pool": {
+			identity: &subscriptionPoolLeaseResourceIdentityModel{
+				SubscriptionId: types.StringValue("00000000-0000-0000-0000-000000000004"),
+				Pool:           types.StringNull(),
+			},
+			expectedSubscriptionId: "00000000-0000-0000-0000-000000000004",
+		},
+		"empty pool": {
+			id:          "/00000000-0000-0000-0000-000000000005",
+			expectError: true,
+		},
+		"empty subscription id": {
+			id:          "sandbox/",
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			req := resource.ImportStateRequest{ID: testCase.id}
+			if testCase.identity != nil {
+				req.Identity = newSubscriptionPoolLeaseIdentity(t)
+				if diags := req.Identity.Set(ctx, testCase.identity); diags.HasError() {
+					t.Fatalf("unexpected diagnostics setting the identity: %v", diags)
+				}
+			}
+			resp := resource.ImportStateResponse{
+				State:    newSubscriptionPoolLeaseState(t),
+				Identity: newSubscriptionPoolLeaseIdentity(t),
+			}
+
+			(&subscriptionPoolLeaseResource{}).ImportState(ctx, req, &resp)
+			if testCase.expectError {
+				if !resp.Diagnostics.HasError() {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+
+			lease := getSubscriptionPoolLeaseState(t, resp.State)
+			if got := lease.SubscriptionId.ValueString(); got != testCase.expectedSubscriptionId {
+				t.Errorf("subscription_id = %q, want %q", got, testCase.expectedSubscriptionId)
+			}
+			if testCase.expectedPool == "" && !lease.Pool.IsNull() {
+				t.Errorf("pool = %q, want null", lease.Pool.ValueString())
+			}
+			if got := lease.Pool.ValueString(); got != testCase.expectedPool {
+				t.Errorf("pool = %q, want %q", got, testCase.expectedPool)
+			}
+
+			var identity subscriptionPoolLeaseResourceIdentityModel
+			if diags := resp.Identity.Get(ctx, &identity); diags.HasError() {
+				t.Fatalf("unexpected diagnostics reading the identity: %v", diags)
+			}
+			if got := identity.SubscriptionId.ValueString(); got != testCase.expectedSubscriptionId {
+				t.Errorf("identity subscription_id = %q, want %q", got, testCase.expectedSubscriptionId)
+			}
+			if got := identity.Pool.ValueString(); got != testCase.expectedPool {
+				t.Errorf("identity pool = %q, want %q", got, testCase.expectedPool)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package provider
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ resource.ResourceWithUpgradeState = &subscriptionPoolLeaseResource{}
+
+// subscriptionPoolLeaseResourceModelV0 is the state layout before schema version 1.
+// It contains the misspelled actual_parant_management_group attribute, and Read used to
+// store the qualified ID in subscription_id.
+type subscriptionPoolLeaseResourceModelV0 struct {
+	TargetManagementGroupName    types.String `tfsdk:"target_management_group_name"`
+	TargetSubscriptionName       types.String `tfsdk:"target_subscription_name"`
+	SubscriptionId               types.String `tfsdk:"subscription_id"`
+	QualifiedSubscriptionId      types.String `tfsdk:"qualified_subscription_id"`
+	FullyQualifiedSubscriptionId types.String `tfsdk:"fully_qualified_subscription_id"`
+	ActualParentManagementGroup  types.String `tfsdk:"actual_parant_management_group"`
+}
+
+// UpgradeState migrates states written by older versions of the provider.
+func (r *subscriptionPoolLeaseResource) UpgradeState(_ context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		0: {
+			PriorSchema: &schema.Schema{
+				Attributes: map[string]schema.Attribute{
+					"target_management_group_name": schema.StringAttribute{
+						Required: true,
+					},
+					"target_subscription_name": schema.StringAttribute{
+						Required: true,
+					},
+					"subscription_id": schema.StringAttribute{
+						Computed: true,
+					},
+					"qualified_subscription_id": schema.StringAttribute{
+						Computed: true,
+					},
+					"fully_qualified_subscription_id": schema.StringAttribute{
+						Computed: true,
+					},
+					"actual_parant_management_group": schema.StringAttribute{
+						Computed: true,
+					},
+				},
+			},
+			StateUpgrader: upgradeSubscriptionPoolLeaseStateV0toV1,
+		},
+	}
+}
+
+func upgradeSubscriptionPoolLeaseStateV0toV1(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+	var prior subscriptionPoolLeaseResourceModelV0
+	resp.Diagnostics.Append(req.State.Get(ctx, &prior)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	subscriptionId := strings.TrimPrefix(prior.SubscriptionId.ValueString(), subscriptionIdPrefix)
+	upgraded := subscriptionPoolLeaseResourceModel{
+		TargetManagementGroupName:    prior.TargetManagementGroupName,
+		TargetSubscriptionName:       prior.TargetSubscriptionName,
+		SubscriptionId:               types.StringValue(subscriptionId),
+		QualifiedSubscriptionId:      types.StringValue(subscriptionIdPrefix + subscriptionId),
+		FullyQualifiedSubscriptionId: prior.FullyQualifiedSubscriptionId,
+		ActualParentManagementGroup:  prior.ActualParentManagementGroup,
+		Timeouts:                     nullTimeouts(),
+	}
+
+	if !upgraded.ActualParentManagementGroup.IsNull() {
+		upgraded.FullyQualifiedSubscriptionId = types.StringValue(managementGroupIdPrefix + upgraded.ActualParentManagementGroup.ValueString() + subscriptionIdPrefix + subscriptionId)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, upgraded)...)
+}
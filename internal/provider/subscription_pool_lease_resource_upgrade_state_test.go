@@ -0,0 +1,140 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// newSubscriptionPoolLeaseState returns an empty state of the current lease schema, ready to be set by an upgrader or mover.
+func newSubscriptionPoolLeaseState(t *testing.T) tfsdk.State {
+	t.Helper()
+	ctx := context.Background()
+
+	var resp resource.SchemaResponse
+	NewSubscriptionPoolLeaseResource().Schema(ctx, resource.SchemaRequest{}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected schema diagnostics: %v", resp.Diagnostics)
+	}
+	return tfsdk.State{
+		Schema: resp.Schema,
+		Raw:    tftypes.NewValue(resp.Schema.Type().TerraformType(ctx), nil),
+	}
+}
+
+// getSubscriptionPoolLeaseState reads the lease model back from the state.
+func getSubscriptionPoolLeaseState(t *testing.T, state tfsdk.State) subscriptionPoolLeaseResourceModel {
+	t.Helper()
+
+	var lease subscriptionPoolLeaseResourceModel
+	if diags := state.Get(context.Background(), &lease); diags.HasError() {
+		t.Fatalf("unexpected diagnostics reading the state: %v", diags)
+	}
+	return lease
+}
+
+func TestUpgradeSubscriptionPoolLeaseStateV0toV1(t *testing.T) {
+	ctx := context.Background()
+	priorSchema := (&subscriptionPoolLeaseResource{}).UpgradeState(ctx)[0].PriorSchema
+	priorType := priorSchema.Type().TerraformType(ctx)
+
+	testCases := map[string]struct {
+		prior map[string]tftypes.Value
+
+		expectedSubscriptionId               string
+		expectedQualifiedSubscriptionId      string
+		expectedFullyQualifiedSubscriptionId string
+		expectedActualParentManagementGroup  string
+	}{
+		"qualified subscription id": {
+			prior: map[string]tftypes.Value{
+				"target_management_group_name":    tftypes.NewValue(tftypes.String, "landing-zones"),
+				"target_subscription_name":        tftypes.NewValue(tftypes.String, "team-a"),
+				"subscription_id":                 tftypes.NewValue(tftypes.String, "/subscriptions/00000000-0000-0000-0000-000000000001"),
+				"qualified_subscription_id":       tftypes.NewValue(tftypes.String, "/subscriptions/00000000-0000-0000-0000-000000000001"),
+				"fully_qualified_subscription_id": tftypes.NewValue(tftypes.String, "/providers/Microsoft.Management/managementGroups/landing-zones/subscriptions/00000000-0000-0000-0000-000000000001"),
+				"actual_parant_management_group":  tftypes.NewValue(tftypes.String, "landing-zones"),
+			},
+			expectedSubscriptionId:               "00000000-0000-0000-0000-000000000001",
+			expectedQualifiedSubscriptionId:      "/subscriptions/00000000-0000-0000-0000-000000000001",
+			expectedFullyQualifiedSubscriptionId: "/providers/Microsoft.Management/managementGroups/landing-zones/subscriptions/00000000-0000-0000-0000-000000000001",
+			expectedActualParentManagementGroup:  "landing-zones",
+		},
+		"fully qualified subscription id recomputed from the parent": {
+			prior: map[string]tftypes.Value{
+				"target_management_group_name":    tftypes.NewValue(tftypes.String, "landing-zones"),
+				"target_subscription_name":        tftypes.NewValue(tftypes.String, "team-a"),
+				"subscription_id":                 tftypes.NewValue(tftypes.String, "00000000-0000-0000-0000-000000000002"),
+				"qualified_subscription_id":       tftypes.NewValue(tftypes.String, "/subscriptions/00000000-0000-0000-0000-000000000002"),
+				"fully_qualified_subscription_id": tftypes.NewValue(tftypes.String, "/providers/Microsoft.Management/managementGroups/Crossnative/subscriptions/00000000-0000-0000-0000-000000000002"),
+				"actual_parant_management_group":  tftypes.NewValue(tftypes.String, "sandboxes"),
+			},
+			expectedSubscriptionId:               "00000000-0000-0000-0000-000000000002",
+			expectedQualifiedSubscriptionId:      "/subscriptions/00000000-0000-0000-0000-000000000002",
+			expectedFullyQualifiedSubscriptionId: "/providers/Microsoft.Management/managementGroups/sandboxes/subscriptions/00000000-0000-0000-0000-000000000002",
+			expectedActualParentManagementGroup:  "sandboxes",
+		},
+		"null parent": {
+			prior: map[string]tftypes.Value{
+				"target_management_group_name":    tftypes.NewValue(tftypes.String, "landing-zones"),
+				"target_subscription_name":        tftypes.NewValue(tftypes.String, "team-a"),
+				"subscription_id":                 tftypes.NewValue(tftypes.String, "/subscriptions/00000000-0000-0000-0000-000000000003"),
+				"qualified_subscription_id":       tftypes.NewValue(tftypes.String, nil),
+				"fully_qualified_subscription_id": tftypes.NewValue(tftypes.String, "/providers/Microsoft.Management/managementGroups/landing-zones/subscriptions/00000000-0000-0000-0000-000000000003"),
+				"actual_parant_management_group":  tftypes.NewValue(tftypes.String, nil),
+			},
+			expectedSubscriptionId:               "00000000-0000-0000-0000-000000000003",
+			expectedQualifiedSubscriptionId:      "/subscriptions/00000000-0000-0000-0000-000000000003",
+			expectedFullyQualifiedSubscriptionId: "/providers/Microsoft.Management/managementGroups/landing-zones/subscriptions/00000000-0000-0000-0000-000000000003",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := resource.UpgradeStateRequest{
+				State: &tfsdk.State{
+					Schema: priorSchema,
+					Raw:    tftypes.NewValue(priorType, testCase.prior),
+				},
+			}
+			resp := resource.UpgradeStateResponse{
+				State: newSubscriptionPoolLeaseState(t),
+			}
+
+			upgradeSubscriptionPoolLeaseStateV0toV1(ctx, req, &resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+
+			lease := getSubscriptionPoolLeaseState(t, resp.State)
+			if got := lease.TargetManagementGroupName.ValueString(); got != "landing-zones" {
+				t.Errorf("target_management_group_name = %q, want %q", got, "landing-zones")
+			}
+			if got := lease.TargetSubscriptionName.ValueString(); got != "team-a" {
+				t.Errorf("target_subscription_name = %q, want %q", got, "team-a")
+			}
+			if got := lease.SubscriptionId.ValueString(); got != testCase.expectedSubscriptionId {
+				t.Errorf("subscription_id = %q, want %q", got, testCase.expectedSubscriptionId)
+			}
+			if got := lease.QualifiedSubscriptionId.ValueString(); got != testCase.expectedQualifiedSubscriptionId {
+				t.Errorf("qualified_subscription_id = %q, want %q", got, testCase.expectedQualifiedSubscriptionId)
+			}
+			if got := lease.FullyQualifiedSubscriptionId.ValueString(); got != testCase.expectedFullyQualifiedSubscriptionId {
+				t.Errorf("fully_qualified_subscription_id = %q, want %q", got, testCase.expectedFullyQualifiedSubscriptionId)
+			}
+			if testCase.expectedActualParentManagementGroup == "" {
+				if !lease.ActualParentManagementGroup.IsNull() {
+					t.Errorf("actual_parent_management_group = %q, want null", lease.ActualParentManagementGroup.ValueString())
+				}
+			} else if got := lease.ActualParentManagementGroup.ValueString(); got != testCase.expectedActualParentManagementGroup {
+				t.Errorf("actual_parent_management_group = %q, want %q", got, testCase.expectedActualParentManagementGroup)
+			}
+			if !lease.Pool.IsNull() {
+				t.Errorf("pool = %q, want null", lease.Pool.ValueString())
+			}
+		})
+	}
+}